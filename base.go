@@ -1,15 +1,36 @@
 package gopp
 
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
 type BaseI interface {
 	IsA(className string) bool
 	InstanceOf(className string) bool
 	Class() string
+	I() BaseI
+	Clone() BaseI
 }
 
 type Base struct {
-	_i BaseI // the internal copy of the subclass struct inside of an interface
+	_i     BaseI  // the internal copy of the subclass struct inside of an interface
+	noCopy noCopy // lets go vet report objects that are copied by value
 }
 
+// noCopy makes "go vet" report gopp objects that are copied by value, since a copy would still point to the original
+// object through its interface, and virtual calls on the copy would go to the original. Use Clone instead.
+// See https://golang.org/issues/8005 for how this works.
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
+
 // Init saves a copy of the subclass inside of an interface. It is to be called by the "New" function of the subclass.
 // We can type cast this interface at will to any intermediate class in order to virtually use its methods. You should
 // not normally call this function yourself.
@@ -17,6 +38,12 @@ func (b *Base) Init(i BaseI) {
 	b._i = i
 }
 
+// InitDefaults sets the members of the object to their default values. Base has no members. Classes that have members
+// with default values override this and call their superclass first. It is called by the "New" function of the
+// subclass before Construct.
+func (b *Base) InitDefaults() {
+}
+
 // Construct is a typical constructor that can be used to initialize an inheritance hierarchy. Subclasses should call their
 // superclasses.
 func (b *Base) Construct() {
@@ -45,3 +72,251 @@ func (b *Base) IsA(className string) bool {
 func (b *Base) Class() string {
 	return "gopp.Base"
 }
+
+// Clone returns a new Base. Classes override this to return a copy of themselves.
+func (b *Base) Clone() BaseI {
+	c := new(Base)
+	c.Init(c)
+	return c
+}
+
+// CloneMembers copies the members of Base into c. Base has no members to copy. Classes define their own version that
+// copies their members after calling their superclass.
+func (b *Base) CloneMembers(c *Base) {
+}
+
+// CloneSlice returns a copy of s. It is used by the generated Clone functions so that a copy of an object does not
+// share slices with the original.
+func CloneSlice[S ~[]E, E any](s S) S {
+	return slices.Clone(s)
+}
+
+// CloneMap returns a copy of m. It is used by the generated Clone functions so that a copy of an object does not
+// share maps with the original.
+func CloneMap[M ~map[K]V, K comparable, V any](m M) M {
+	return maps.Clone(m)
+}
+
+// As casts obj to the type T, which is normally the interface of a class. The cast is done on obj.I(), so that the
+// most derived object is used even if obj refers to an embedded superclass. The check is done using Go's interface
+// rules, so any object that has all the methods of T will match. The generated As<Class> functions also check that
+// the object is actually of the class.
+func As[T any](obj BaseI) (t T, ok bool) {
+	if obj == nil {
+		return
+	}
+	i := obj.I()
+	if i == nil {
+		return
+	}
+	t, ok = i.(T)
+	return
+}
+
+var registry = make(map[string]func() BaseI)
+var registryMutex sync.RWMutex
+
+// ClassName returns the name of the class of obj qualified by the import path of its package, like
+// "github.com/you/shapes.Circle", which is the name that classes are registered under, and that the JSON of objects
+// records. Classes of the same name in different packages have different qualified names.
+func ClassName(obj BaseI) string {
+	if i := obj.I(); i != nil {
+		obj = i
+	}
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.PkgPath() + "." + obj.Class()
+}
+
+// bareName returns the name of a class without the import path that qualifies it.
+func bareName(className string) string {
+	return className[strings.LastIndex(className, ".")+1:]
+}
+
+// isClass reports whether className is the qualified name of the class of obj or of one of its superclasses. The
+// superclasses are found from the structs that the struct of each class embeds first, and their names are found by
+// calling Class on new objects of them, since the generated Class functions do not use the object.
+func isClass(obj BaseI, className string) bool {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		c, ok := reflect.New(t.Elem()).Interface().(BaseI)
+		if !ok {
+			return false
+		}
+		if t.Elem().PkgPath()+"."+c.Class() == className {
+			return true
+		}
+		if t.Elem().NumField() == 0 || !t.Elem().Field(0).Anonymous {
+			return false
+		}
+		t = reflect.PointerTo(t.Elem().Field(0).Type)
+	}
+	return false
+}
+
+// RegisterClass registers a function that creates a new, initialized object of the named class, where the name is
+// qualified by the import path of the package of the class, as returned by ClassName. It is called by the code generated
+// for classes that support JSON, so that UnmarshalPolymorphic can create objects by class name. RegisterClass panics if
+// a class was already registered with the same name.
+func RegisterClass(className string, f func() BaseI) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if _, ok := registry[className]; ok {
+		panic("gopp: class " + className + " registered twice")
+	}
+	registry[className] = f
+}
+
+// NewObject creates a new object of the named class using the function given to RegisterClass. The name is normally
+// qualified by the import path of the package of the class, but can be the bare name of the class if only one
+// registered class has that name. It returns false if the class was not registered, or if the bare name is ambiguous.
+func NewObject(className string) (BaseI, bool) {
+	registryMutex.RLock()
+	f, ok := registry[className]
+	if !ok && !strings.Contains(className, ".") {
+		for name, g := range registry {
+			if bareName(name) == className {
+				if f != nil {
+					f = nil // more than one class has the name
+					break
+				}
+				f = g
+			}
+		}
+		ok = f != nil
+	}
+	registryMutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return f(), true
+}
+
+// ClassKey is the key in the JSON of an object that holds the name of its class, qualified by the import path of its
+// package.
+const ClassKey = "@class"
+
+// Fields holds the encoded members of an object while it is being decoded from JSON.
+type Fields map[string]json.RawMessage
+
+// Decode decodes the named field into v, which must be a pointer. Nothing is done if the field is not present.
+// If v points to an interface, like the interface of a class, the field is decoded with UnmarshalPolymorphic.
+func (f Fields) Decode(name string, v interface{}) error {
+	raw, ok := f[name]
+	if !ok {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Interface || rv.Elem().NumMethod() == 0 {
+		return json.Unmarshal(raw, v)
+	}
+	dest := rv.Elem()
+	if string(raw) == "null" {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+	obj, err := UnmarshalPolymorphic(raw)
+	if err != nil {
+		return err
+	}
+	ov := reflect.ValueOf(obj)
+	if !ov.Type().AssignableTo(dest.Type()) {
+		return fmt.Errorf("gopp: cannot assign a %s to field %s of type %s", obj.Class(), name, dest.Type())
+	}
+	dest.Set(ov)
+	return nil
+}
+
+// DecodeRequired is like Decode, but returns an error if the field is not present.
+func (f Fields) DecodeRequired(name string, v interface{}) error {
+	if _, ok := f[name]; !ok {
+		return fmt.Errorf("gopp: required field %s is missing", name)
+	}
+	return f.Decode(name, v)
+}
+
+// fieldMarshaler is implemented by classes that support JSON.
+type fieldMarshaler interface {
+	MarshalFields(m map[string]interface{})
+	UnmarshalFields(f Fields) error
+}
+
+// MarshalFields adds the members of the object to m. Base has no members to add. Classes that support JSON override
+// this and call their superclass first.
+func (b *Base) MarshalFields(m map[string]interface{}) {
+}
+
+// UnmarshalFields decodes the members of the object from f. Base has no members to decode. Classes that support JSON
+// override this and call their superclass first.
+func (b *Base) UnmarshalFields(f Fields) error {
+	return nil
+}
+
+// MarshalObject encodes obj as a JSON object containing the members of its class and all of its superclasses, plus its
+// qualified class name under ClassKey. It is called by the generated MarshalJSON functions.
+func MarshalObject(obj BaseI) ([]byte, error) {
+	if i := obj.I(); i != nil {
+		obj = i
+	}
+	m := map[string]interface{}{ClassKey: ClassName(obj)}
+	if f, ok := obj.(fieldMarshaler); ok {
+		f.MarshalFields(m)
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalObject decodes the JSON in data into obj. The class recorded in data, if any, must be the class of obj or
+// one of its superclasses, which are compared by their qualified names, so that a class of the same name in another
+// package does not match. A bare class name is compared with IsA. It is called by the generated UnmarshalJSON
+// functions.
+func UnmarshalObject(obj BaseI, data []byte) error {
+	var f Fields
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	if i := obj.I(); i != nil {
+		obj = i
+	}
+	if raw, ok := f[ClassKey]; ok {
+		var className string
+		if err := json.Unmarshal(raw, &className); err != nil {
+			return err
+		}
+		matches := obj.IsA(className)
+		if strings.Contains(className, ".") {
+			matches = isClass(obj, className)
+		}
+		if !matches {
+			return fmt.Errorf("gopp: cannot decode a %s into a %s", className, obj.Class())
+		}
+	}
+	if m, ok := obj.(fieldMarshaler); ok {
+		return m.UnmarshalFields(f)
+	}
+	return nil
+}
+
+// UnmarshalPolymorphic decodes JSON that was encoded by MarshalObject into a new object of the class recorded in the
+// JSON. The class must have been registered with RegisterClass, which happens automatically for classes that support
+// JSON. JSON that records a bare class name is accepted if only one registered class has that name.
+func UnmarshalPolymorphic(data []byte) (BaseI, error) {
+	var c struct {
+		Class string `json:"@class"`
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if c.Class == "" {
+		return nil, fmt.Errorf("gopp: the JSON does not have a %s", ClassKey)
+	}
+	obj, ok := NewObject(c.Class)
+	if !ok {
+		return nil, fmt.Errorf("gopp: class %s is not registered", c.Class)
+	}
+	if err := UnmarshalObject(obj, data); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,265 @@
+package gopp
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"reflect"
+)
+
+// Equal returns true if a and b are equal. It is used by the Equal functions generated for equatable classes to
+// compare members. Values are compared like reflect.DeepEqual compares them, except that objects of equatable classes,
+// and any other values that have both a Hash function and an Equal function, are compared with their own Equal
+// function. A reference back to a containing value is only equal to a reference back to the containing value at the
+// same depth, so that values that are Equal always have the same Hash.
+func Equal(a, b interface{}) bool {
+	return equalValue(reflect.ValueOf(a), reflect.ValueOf(b), nil, nil)
+}
+
+// hasher is implemented by equatable classes.
+type hasher interface {
+	Hash() uint64
+}
+
+// equalMethod returns the Equal function of v if v compares itself with its own Equal and Hash functions, which is true
+// of objects of equatable classes. The Equal function must take one value, which v can be assigned to, and return a
+// bool.
+func equalMethod(v reflect.Value) (reflect.Value, bool) {
+	if !v.CanInterface() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return reflect.Value{}, false
+	}
+	if _, ok := v.Interface().(hasher); !ok {
+		return reflect.Value{}, false
+	}
+	m := v.MethodByName("Equal")
+	if !m.IsValid() {
+		return reflect.Value{}, false
+	}
+	t := m.Type()
+	if t.NumIn() != 1 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool || !v.Type().AssignableTo(t.In(0)) {
+		return reflect.Value{}, false
+	}
+	return m, true
+}
+
+// ref identifies a pointer, map or slice on the path from the value being compared or hashed to the current value,
+// so that references back to a containing value end the walk.
+type ref struct {
+	t reflect.Type
+	p uintptr
+	n int
+}
+
+// refOf returns the ref of v, or false if v is not a pointer, map or slice that can contain itself.
+func refOf(v reflect.Value) (ref, bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() || (v.Kind() != reflect.Ptr && v.Len() == 0) {
+			return ref{}, false
+		}
+		r := ref{v.Type(), v.Pointer(), 0}
+		if v.Kind() == reflect.Slice {
+			r.n = v.Len()
+		}
+		return r, true
+	}
+	return ref{}, false
+}
+
+// depth returns the position of r on path, or -1 if it is not on the path.
+func depth(path []ref, r ref) int {
+	for i, p := range path {
+		if p == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// equalValue compares a and b, which are reached through pathA and pathB.
+func equalValue(a, b reflect.Value, pathA, pathB []ref) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	if m, ok := equalMethod(a); ok {
+		return m.Call([]reflect.Value{b})[0].Bool()
+	}
+	if ra, ok := refOf(a); ok {
+		rb, ok := refOf(b)
+		if !ok {
+			return false
+		}
+		da, db := depth(pathA, ra), depth(pathB, rb)
+		if da >= 0 || db >= 0 {
+			return da == db
+		}
+		pathA, pathB = append(pathA, ra), append(pathB, rb)
+	}
+
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalValue(a.Elem(), b.Elem(), pathA, pathB)
+	case reflect.Slice:
+		if a.IsNil() != b.IsNil() {
+			return false
+		}
+		fallthrough
+	case reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValue(a.Index(i), b.Index(i), pathA, pathB) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() || a.Len() != b.Len() {
+			return false
+		}
+		iter := a.MapRange()
+		for iter.Next() {
+			bv := b.MapIndex(iter.Key())
+			if !bv.IsValid() || !equalValue(iter.Value(), bv, pathA, pathB) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !equalValue(a.Field(i), b.Field(i), pathA, pathB) {
+				return false
+			}
+		}
+		return true
+	case reflect.Func:
+		// Functions are only equal if they are nil
+		return a.IsNil() && b.IsNil()
+	default:
+		// Channels and unsafe pointers are equal if they are the same
+		return a.Pointer() == b.Pointer()
+	}
+}
+
+// Hash returns a hash of the given values. Values that are Equal have the same hash, so it can be used together with
+// Equal. Values that Equal compares with their own Equal function, like objects of equatable classes, are hashed with
+// their own Hash function. It is used by the Hash functions generated for equatable classes.
+func Hash(values ...interface{}) uint64 {
+	h := fnv.New64a()
+	for _, v := range values {
+		hashValue(h, reflect.ValueOf(v), nil)
+	}
+	return h.Sum64()
+}
+
+// hashValue adds v, which is reached through path, to h. It follows the same rules as equalValue.
+func hashValue(h hash.Hash64, v reflect.Value, path []ref) {
+	var buf [8]byte
+	writeUint := func(u uint64) {
+		binary.LittleEndian.PutUint64(buf[:], u)
+		h.Write(buf[:])
+	}
+
+	if !v.IsValid() {
+		writeUint(0)
+		return
+	}
+	if _, ok := equalMethod(v); ok {
+		writeUint(v.Interface().(hasher).Hash())
+		return
+	}
+	if r, ok := refOf(v); ok {
+		if d := depth(path, r); d >= 0 {
+			// A reference back to a containing value, which is identified by its depth
+			writeUint(2)
+			writeUint(uint64(d))
+			return
+		}
+		path = append(path, r)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint(1)
+		} else {
+			writeUint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeUint(floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		writeUint(floatBits(real(v.Complex())))
+		writeUint(floatBits(imag(v.Complex())))
+	case reflect.String:
+		writeUint(uint64(v.Len()))
+		h.Write([]byte(v.String()))
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			writeUint(0)
+			return
+		}
+		writeUint(1)
+		hashValue(h, v.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		writeUint(uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i), path)
+		}
+	case reflect.Map:
+		// Map entries are hashed separately and added together so that their order does not matter. Keys are compared
+		// with ==, so they are hashed on their own, without the path.
+		var sum uint64
+		iter := v.MapRange()
+		for iter.Next() {
+			eh := fnv.New64a()
+			hashValue(eh, iter.Key(), nil)
+			hashValue(eh, iter.Value(), path)
+			sum += eh.Sum64()
+		}
+		writeUint(uint64(v.Len()))
+		writeUint(sum)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i), path)
+		}
+	default:
+		// Functions are only equal if they are nil, and channels and unsafe pointers if they are the same
+		if v.IsNil() {
+			writeUint(0)
+		} else {
+			writeUint(1)
+		}
+	}
+}
+
+// floatBits returns the bits of f, treating negative zero as zero, since they are equal.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
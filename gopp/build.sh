@@ -1,3 +0,0 @@
-#!/usr/bin/env bash
-
-go build -o $GOPATH/bin/gopp *.go
\ No newline at end of file
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/spekary/gopp/translate"
+)
+
+// runCheck runs the check command, which checks .gpp files without writing any files, and returns the exit code.
+// The paths are the same as the paths of the generate command, and the current directory is checked if no paths are
+// given.
+func runCheck(args []string) int {
+	var opts translate.Options
+	gf := genFlags{jobs: runtime.GOMAXPROCS(0)}
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.BoolVar(&opts.JSON, "json", false, "check the code that is generated with the -json flag")
+	fs.StringVar(&gf.templates, "templates", "", "check the code that is generated with the templates in this directory")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: gopp check [-json] [-templates dir] [path ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := expandArgs(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	code := 0
+	s := translate.NewSession(opts)
+	if len(addFiles(s, files, gf)) != len(files) {
+		code = 1
+	}
+	for _, d := range s.Check() {
+		fmt.Fprintln(os.Stderr, d)
+		code = 1
+	}
+	return code
+}
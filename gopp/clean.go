@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runClean runs the clean command, which removes the .go files that gopp generated for .gpp files that do not exist
+// any more, and returns the exit code. The paths are directories, or directories followed by "/...", and the current
+// directory is cleaned if no paths are given.
+func runClean(args []string) int {
+	var dryRun bool
+	var outDir string
+	fs := flag.NewFlagSet("clean", flag.ContinueOnError)
+	fs.BoolVar(&dryRun, "n", false, "print the files that would be removed, without removing them")
+	fs.StringVar(&outDir, "o", "", "the output directory the files were generated in")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: gopp clean [-n] [-o outputDir] [dir ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	dirs, err := argDirs(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	code := 0
+	files, err := orphans(dirs, outDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		code = 1
+	}
+	for _, file := range files {
+		fmt.Println("rm " + file)
+		if dryRun {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spekary/gopp/translate"
+)
+
+// configure sets the configuration of the directories of the given .gpp files in the session from the gopp.toml files
+// that apply to them, with the templates in templateDir, if it is given. It prints the problems with the configuration
+// files to standard error, and returns the files whose configuration could be read.
+func configure(s *translate.Session, files []string, templateDir string) []string {
+	loaded := make(map[string]bool)
+	var out []string
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		ok, seen := loaded[dir]
+		if !seen {
+			config, err := loadConfig(dir, templateDir)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			} else {
+				s.SetConfig(dir, config)
+			}
+			ok = err == nil
+			loaded[dir] = ok
+		}
+		if ok {
+			out = append(out, file)
+		}
+	}
+	return out
+}
+
+// loadConfig returns the configuration of the .gpp files in dir. If templateDir is given, its templates are used
+// instead of the templates of the configuration.
+func loadConfig(dir string, templateDir string) (translate.Config, error) {
+	config, _, err := translate.LoadConfig(dir)
+	if err != nil || templateDir == "" {
+		return config, err
+	}
+	config.Templates, err = translate.LoadTemplates(templateDir)
+	return config, err
+}
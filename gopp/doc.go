@@ -34,6 +34,9 @@ A class definition begins with the word "class", followed by a class name, the r
 To create a base class, you should extend the "gopp.Base" class. The Base class is a struct and interface combination that
 implement basic object functions that are often found in object oriented languages.
 
+The superclass can be followed by modifiers that add features to the class. The "equatable" modifier generates Equal()
+and Hash() functions that compare and hash all of the members of the class and its superclasses.
+
 Within a class, declare members the same way you would declare a member of a go struct, with a name followed by a type.
 
 For example:
@@ -59,11 +62,31 @@ parent::Construct(<vars>)
 parent:: will always get substituted by the class after the "extends" keyword.
 
 IsA() and Class() functions are automatically added so you can test whether a particular object belongs to a class hierarchy
-or is a particular class without having to do type juggling or reflection.
+or is a particular class without having to do type juggling or reflection. Typed versions are also generated for each class.
+For a Duck class, IsDuck(obj) tests whether obj is a Duck or a subclass of Duck, and AsDuck(obj) returns obj as a DuckI
+so that you can call its methods.
 
 The resulting struct name is the same as the class name, and the interface name is the class name followed by "I". So,
 a Duck interface is DuckI (pronounced Duckee, as in "duck like"). Generally you will work with the interface when
-creating these objects from standard Go code.
+creating these objects from standard Go code. These names, along with the names of the New functions and of the
+receivers, can be changed in a gopp.toml file, as described in the README. The templates that generate the code
+can be replaced too, and gopp.toml can list generators, which are programs that add more code for each class.
+
+Formatting:
+
+gofmt cannot read .gpp files, so use "gopp fmt" instead. It takes the same -l, -w and -d flags as gofmt, and formats
+the files and directories given to it, or standard input if none are given.
+
+Checking:
+
+"gopp check" checks .gpp files without writing any files. It validates the use of override, and type checks the code
+that would be generated along with the rest of the package, reporting problems at their lines in the .gpp files.
+
+Mocks:
+
+"gopp mock" generates a mock of the interface of each class for tests, with a function field for each method of the
+interface and its superclasses, and records the calls of the methods. The mocks of person.gpp are written to
+person_mock_test.go, or to a separate package with -o.
 
 */
 package main
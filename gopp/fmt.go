@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spekary/gopp/internal/diff"
+	"github.com/spekary/gopp/translate"
+)
+
+// fmtFlags are the options of the fmt command.
+type fmtFlags struct {
+	list  bool // list the files whose formatting is different
+	write bool // write the result to the source file
+	diff  bool // display diffs
+}
+
+// runFmt runs the fmt command, which formats .gpp files the way gofmt formats .go files, and returns the exit code.
+// Directories are searched for .gpp files recursively. If no files are given, it formats standard input.
+func runFmt(args []string) int {
+	var ff fmtFlags
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.BoolVar(&ff.list, "l", false, "list files whose formatting differs from gopp fmt's")
+	fs.BoolVar(&ff.write, "w", false, "write result to (source) file instead of stdout")
+	fs.BoolVar(&ff.diff, "d", false, "display diffs instead of rewriting files")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: gopp fmt [-l] [-w] [-d] [path ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() == 0 {
+		if ff.write {
+			fmt.Fprintln(os.Stderr, "error: cannot use -w with standard input")
+			return 2
+		}
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err == nil {
+			err = formatFile("<standard input>", src, ff)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		return 0
+	}
+
+	code := 0
+	for _, path := range fs.Args() {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || (file != path && !strings.HasSuffix(file, ".gpp")) {
+				return nil
+			}
+			src, err := ioutil.ReadFile(file)
+			if err == nil {
+				err = formatFile(file, src, ff)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				code = 2
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 2
+		}
+	}
+	return code
+}
+
+// formatFile formats the source of one file and reports or writes the result as requested by ff.
+func formatFile(file string, src []byte, ff fmtFlags) error {
+	res, err := translate.Format(file, src)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(src, res) {
+		if !ff.list && !ff.write && !ff.diff {
+			os.Stdout.Write(res)
+		}
+		return nil
+	}
+
+	if ff.list {
+		fmt.Println(file)
+	}
+	if ff.write {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(file, res, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if ff.diff {
+		fmt.Printf("diff -u %s.orig %s\n", file, file)
+		os.Stdout.Write(diff.Unified(file+".orig", file, src, res))
+	}
+	if !ff.list && !ff.write && !ff.diff {
+		os.Stdout.Write(res)
+	}
+	return nil
+}
@@ -1,111 +1,297 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
-	"os/exec"
+
+	"github.com/spekary/gopp/translate"
 )
 
-func processFile(file string, outDir string) {
-	buf, err := ioutil.ReadFile(file)
+// genFlags are the options of generating .go files.
+type genFlags struct {
+	outDir    string // the directory to write the files to
+	jobs      int    // the number of files to parse and generate at the same time
+	stdout    bool   // write the generated code to standard output instead of to files
+	templates string // the directory of templates that replace the templates of gopp and of the configuration
+}
 
-	s := fmt.Sprintf("%s", buf)
+// processFiles processes the given .gpp files, using up to jobs goroutines, and reports whether all of them were
+// generated. All of the files are added to the translation session before any of them are generated, so that classes
+// can extend classes that are declared in any of the files of the same package. Problems are printed in the order of
+// the files, whatever the number of jobs.
+func processFiles(files []string, gf genFlags, opts translate.Options) bool {
+	s := translate.NewSession(opts)
+	added := addFiles(s, files, gf)
+	ok := generateFiles(s, added, gf)
+	return ok && len(added) == len(files)
+}
 
-	if err != nil {
-		fmt.Println(err)
-		return
+// addFiles reads the given .gpp files and adds them to the session, parsing up to gf.jobs of them at the same time.
+// The configuration of each directory comes from its gopp.toml file. It prints the problems with the files to standard
+// error, and returns the files that were added.
+func addFiles(s *translate.Session, files []string, gf genFlags) []string {
+	var sources []translate.Source
+	for _, file := range configure(s, files, gf.templates) {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		sources = append(sources, translate.Source{Name: file, Src: buf})
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("Recovered ", r)
+	var added []string
+	for i, err := range s.AddAll(sources, gf.jobs) {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
 		}
-	}()
-
-	s = ProcessString(s)
-
-	s = "//** This file is code generated by gopp. Do not edit.\n\n\n" + s
-
-	i := strings.LastIndex(file, ".")
-
-	if i < 0 {
-		file = file + ".go"
-	} else {
-		file = file[:i] + ".go"
+		added = append(added, sources[i].Name)
 	}
+	return added
+}
 
-	if outDir != "" {
-		file = outDir + "/" + file
+// generateFiles generates the .go files of .gpp files that were added to the session, up to jobs at the same time,
+// and reports whether all of them were generated. Nothing is generated for a file if the hash in the header of its
+// existing .go file shows that none of its inputs changed, and a file is only written if its content is different, so
+// that its modification time only changes when it needs to. The files are written in order once they are all
+// generated. With the stdout flag, the code of every file is written to standard output instead.
+func generateFiles(s *translate.Session, files []string, gf genFlags) bool {
+	var gen []string
+	var olds [][]byte
+	for _, file := range files {
+		var old []byte
+		if !gf.stdout {
+			old, _ = ioutil.ReadFile(outputPath(file, gf.outDir))
+			if old != nil && upToDate(s, file, old, gf.outDir) {
+				continue
+			}
+		}
+		gen = append(gen, file)
+		olds = append(olds, old)
 	}
-	ioutil.WriteFile(file, []byte(s), os.ModePerm)
 
-	execCommand("go fmt " + file)
+	ok := true
+	results, errs := s.GenerateAll(gen, gf.jobs)
+	for i, file := range gen {
+		if errs[i] != nil {
+			fmt.Fprintln(os.Stderr, errs[i])
+			ok = false
+			continue
+		}
+		if gf.stdout {
+			os.Stdout.Write(results[i].Source)
+			continue
+		}
+		out := outputPath(file, gf.outDir)
+		if !bytes.Equal(olds[i], results[i].Source) && !writeOutput(out, results[i].Source) {
+			ok = false
+		}
+		for suffix, src := range results[i].Files {
+			path := translate.SuffixName(out, suffix)
+			if old, _ := ioutil.ReadFile(path); !bytes.Equal(old, src) && !writeOutput(path, src) {
+				ok = false
+			}
+		}
+	}
+	return ok
 }
 
-/**
-Process a string that is gopp formatted code, and return the go code
-*/
-func ProcessString(input string) string {
-	l := lex(string(input))
-
-	tree := parse(l)
-
-	s := tree.String()
+// upToDate reports whether the generated code of a file and the files written beside it by generators were generated
+// from the current sources, given the code of the generated file. Files whose generators run programs are always
+// generated again, since the programs may have changed without changing the hash.
+func upToDate(s *translate.Session, file string, old []byte, outDir string) bool {
+	if s.RunsPrograms(file) {
+		return false
+	}
+	hash := s.Hash(file)
+	if translate.GeneratedHash(old) != hash {
+		return false
+	}
+	out := outputPath(file, outDir)
+	for _, suffix := range s.FileSuffixes(file) {
+		src, err := ioutil.ReadFile(translate.SuffixName(out, suffix))
+		if err != nil || translate.GeneratedHash(src) != hash {
+			return false
+		}
+	}
+	return true
+}
 
-	return s
+// writeOutput writes generated code to a file, creating its directory if needed, and reports whether it was written.
+func writeOutput(path string, src []byte) bool {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err == nil {
+		err = ioutil.WriteFile(path, src, 0666)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return true
 }
 
+// processStdin generates the .gpp source on standard input and writes the Go code to standard output, and reports
+// whether it was generated.
+func processStdin(gf genFlags, opts translate.Options) bool {
+	src, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	config, err := loadConfig(".", gf.templates)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	opts.Naming, opts.Templates = config.Naming, config.Templates
+	r, err := translate.Translate("<standard input>", src, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	os.Stdout.Write(r.Source)
+	return true
+}
 
-// execCommand wraps exec.Command
-func execCommand(command string) {
-	parts := strings.Split(command, " ")
-	if len(parts) == 0 {
-		return
+// processGoGenerate generates the .gpp file that matches the .go file that go generate is running for, which is named
+// by $GOFILE, and reports whether it was generated. The other .gpp files in the directory that are in the package
+// named by $GOPACKAGE are added to the session too, so that classes can extend their classes.
+func processGoGenerate(gofile string, gopackage string, gf genFlags, opts translate.Options) bool {
+	file := strings.TrimSuffix(gofile, ".go") + ".gpp"
+	if _, err := os.Stat(file); err != nil {
+		fmt.Fprintf(os.Stderr, "gopp: no .gpp file for %s: %v\n", gofile, err)
+		return false
 	}
 
-	cmd := exec.Command(parts[0], parts[1:]...)
-	cmd.Stderr = os.Stderr
+	files := []string{file}
+	for _, f := range dirFiles(".") {
+		if f == file {
+			continue
+		}
+		src, err := ioutil.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		// Files that do not parse are added anyway, so that their errors are reported
+		if pf, err := translate.ParseFile(f, src); err != nil || pf.Package == gopackage {
+			files = append(files, f)
+		}
+	}
 
-	err := cmd.Run()
-	if err != nil {
-		fmt.Println(err)
+	s := translate.NewSession(opts)
+	added := addFiles(s, files, gf)
+	if len(added) != len(files) {
+		return false
 	}
+	return generateFiles(s, files[:1], gf)
 }
 
 func main() {
 	var all bool
-	var outdir string
+	var watchMode bool
+	var verify bool
+	var gf genFlags
+	var opts translate.Options
 	args := os.Args[1:]
 
-	if len(args) == 0 {
-		fmt.Println("Usage: gopp  [-all] [-o outputDir] [file ...]")
+	if len(args) > 0 && args[0] == "fmt" {
+		os.Exit(runFmt(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "check" {
+		os.Exit(runCheck(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "clean" {
+		os.Exit(runClean(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "mock" {
+		os.Exit(runMock(args[1:]))
+	}
+
+	gofile := os.Getenv("GOFILE")
+	if len(args) == 0 && gofile == "" {
+		fmt.Println("Usage: gopp  [-all] [-json] [-o outputDir] [-j n] [-stdout] [-templates dir] [path ...]")
+		fmt.Println("       gopp -verify [-all] [-json] [-o outputDir] [-j n] [path ...]")
+		fmt.Println("       gopp [-json] -")
+		fmt.Println("       gopp -watch [-json] [-o outputDir] [-j n] [path ...]")
+		fmt.Println("       gopp fmt [-l] [-w] [-d] [path ...]")
+		fmt.Println("       gopp check [-json] [-templates dir] [path ...]")
+		fmt.Println("       gopp clean [-n] [-o outputDir] [dir ...]")
+		fmt.Println("       gopp mock [-json] [-templates dir] [-o dir [-pkg name]] [path ...]")
 		fmt.Println("-all: process all .gpp files in the current directory")
-		fmt.Println("-o: specify the output directory")
+		fmt.Println("-json: generate MarshalJSON and UnmarshalJSON functions for each class")
+		fmt.Println("-o: specify the output directory, under which the directories of the .gpp files are repeated")
+		fmt.Println("-j: the number of files to parse and generate at the same time, which defaults to the number of CPUs")
+		fmt.Println("-stdout: write the generated code to standard output instead of to .go files")
+		fmt.Println("-verify: check that the .go files are up to date without writing them, printing a diff for each one that is not")
+		fmt.Println("-templates: the directory of templates that replace the templates that generate the code of a class")
+		fmt.Println("-watch: generate .gpp files in the given paths, or the current directory, whenever they change")
+		fmt.Println("A path is a .gpp file, a directory, or a directory followed by /... to include its subdirectories, like ./...")
+		fmt.Println("The path - reads .gpp source from standard input and writes the go code to standard output.")
+		fmt.Println("Run by go generate without paths, gopp generates the .gpp file that matches $GOFILE.")
 	}
 
-	flag.BoolVar(&all, "all", false, "a boolean flag")
-	flag.StringVar(&outdir, "o", "", "a string var")
+	flag.BoolVar(&all, "all", false, "process all .gpp files in the current directory")
+	flag.BoolVar(&opts.JSON, "json", false, "generate MarshalJSON and UnmarshalJSON functions for each class")
+	flag.StringVar(&gf.outDir, "o", "", "the output directory, under which the directories of the .gpp files are repeated")
+	flag.BoolVar(&watchMode, "watch", false, "generate the .gpp files in the given paths whenever they change")
+	flag.IntVar(&gf.jobs, "j", runtime.GOMAXPROCS(0), "the number of files to parse and generate at the same time")
+	flag.BoolVar(&gf.stdout, "stdout", false, "write the generated code to standard output instead of to .go files")
+	flag.BoolVar(&verify, "verify", false, "check that the .go files are up to date without writing them")
+	flag.StringVar(&gf.templates, "templates", "", "the directory of templates that replace the templates of gopp")
 
 	flag.Parse()
-	//var err error
 
-	if all {
+	ok := true
+	if watchMode {
+		paths := flag.Args()
+		if len(paths) == 0 {
+			paths = []string{"."}
+		}
+		watch(paths, gf, opts)
+	} else if verify {
+		paths := flag.Args()
+		if all || len(paths) == 0 {
+			paths = []string{"."}
+		}
+		files, err := expandArgs(paths)
+		if err == nil {
+			var dirs []string
+			if dirs, err = argDirs(paths); err == nil {
+				ok = verifyFiles(files, dirs, gf, opts)
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else if flag.NArg() == 1 && flag.Arg(0) == "-" {
+		ok = processStdin(gf, opts)
+	} else if all {
 		// Process all files
-		files, _ := filepath.Glob("*.gpp")
+		files := dirFiles(".")
 		if len(files) == 0 {
 			fmt.Println("No .gpp files found in current directory.")
 			return
 		}
-		for _, file := range files {
-			processFile(file, outdir)
-		}
+		ok = processFiles(files, gf, opts)
+	} else if flag.NArg() == 0 && gofile != "" {
+		ok = processGoGenerate(gofile, os.Getenv("GOPACKAGE"), gf, opts)
 	} else {
-		for _, file := range args {
-			processFile(file, outdir)
+		files, err := expandArgs(flag.Args())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		ok = processFiles(files, gf, opts)
+	}
+	if !ok {
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spekary/gopp/translate"
+)
+
+const animalSrc = "package zoo\n\nclass Animal extends gopp.Base {\n\tname string\n}\n"
+const dogSrc = "package zoo\n\nclass Dog extends Animal {\n}\n"
+
+// writeFiles writes files, by their paths relative to dir, creating their directories.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFile returns the content of a file, or an empty string if it cannot be read.
+func readFile(name string) string {
+	data, _ := ioutil.ReadFile(name)
+	return string(data)
+}
+
+func TestStdin(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFiles(t, ".", map[string]string{
+		"in.gpp":    animalSrc,
+		"gopp.toml": "[naming]\ninterface = \"{Name}\"\nstruct = \"{name}\"\n",
+	})
+	in, err := os.Open("in.gpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	out, err := os.Create("out.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdin, stdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = in, out
+	ok := processStdin(genFlags{}, translate.Options{})
+	os.Stdin, os.Stdout = stdin, stdout
+
+	src := readFile("out.go")
+	if !ok || !translate.IsGenerated([]byte(src)) {
+		t.Fatalf("Standard input not generated:\n%s", src)
+	}
+	// The configuration of the current directory is used
+	if !strings.Contains(src, "type Animal interface {") || !strings.Contains(src, "type animal struct {") {
+		t.Errorf("Configuration not used:\n%s", src)
+	}
+}
+
+func TestGoGenerate(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFiles(t, ".", map[string]string{
+		"animal.gpp": animalSrc,
+		"dog.gpp":    dogSrc,
+		// A file of another package in the same directory, which is not added
+		"other.gpp": "package other\n\nclass Dog extends gopp.Base {\n}\n",
+	})
+	gf := genFlags{jobs: 1}
+	if !processGoGenerate("dog.go", "zoo", gf, translate.Options{}) {
+		t.Fatal("dog.gpp not generated")
+	}
+	if src := readFile("dog.go"); !strings.Contains(src, "type Dog struct {\n\tAnimal\n") {
+		t.Errorf("Wrong code for dog.gpp:\n%s", src)
+	}
+	for _, name := range []string{"animal.go", "other.go"} {
+		if _, err := os.Stat(name); err == nil {
+			t.Errorf("%s was generated too", name)
+		}
+	}
+
+	if processGoGenerate("cat.go", "zoo", gf, translate.Options{}) {
+		t.Error("No error for a .go file without a .gpp file")
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spekary/gopp/translate"
+)
+
+// runMock runs the mock command, which generates mocks of the interfaces of the classes in .gpp files for tests, and
+// returns the exit code. The mocks of person.gpp are written to person_mock_test.go beside it, or to person_mock.go in
+// a separate package if an output directory is given. The paths are the same as the paths of the generate command, and
+// the current directory is used if no paths are given.
+func runMock(args []string) int {
+	var opts translate.Options
+	var outDir, pkgName string
+	gf := genFlags{jobs: runtime.GOMAXPROCS(0)}
+	fs := flag.NewFlagSet("mock", flag.ContinueOnError)
+	fs.BoolVar(&opts.JSON, "json", false, "mock the interfaces that are generated with the -json flag")
+	fs.StringVar(&gf.templates, "templates", "", "mock the interfaces that are generated with the templates in this directory")
+	fs.StringVar(&outDir, "o", "", "the directory of a separate package to write the mocks to")
+	fs.StringVar(&pkgName, "pkg", "", "the name of the package of the mocks in the -o directory, which is the name of the directory by default")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: gopp mock [-json] [-templates dir] [-o dir [-pkg name]] [path ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	if pkgName == "" && outDir != "" {
+		abs, err := filepath.Abs(outDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		pkgName = filepath.Base(abs)
+	}
+
+	files, err := expandArgs(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	// The interfaces are found by type checking the packages, so the other files of the packages are added too
+	seen := make(map[string]bool)
+	var pkgFiles []string
+	for i, file := range files {
+		file = filepath.Clean(file)
+		files[i] = file
+		for _, f := range append([]string{file}, dirFiles(filepath.Dir(file))...) {
+			if !seen[f] {
+				seen[f] = true
+				pkgFiles = append(pkgFiles, f)
+			}
+		}
+	}
+	s := translate.NewSession(opts)
+	added := make(map[string]bool)
+	for _, file := range addFiles(s, pkgFiles, gf) {
+		added[file] = true
+	}
+
+	code := 0
+	imports := make(map[string]string)
+	for _, file := range files {
+		if !added[file] {
+			code = 1
+			continue
+		}
+		out := translate.MockName(translate.OutputName(file))
+		var mopts translate.MockOptions
+		if outDir != "" {
+			dir := filepath.Dir(file)
+			if _, ok := imports[dir]; !ok {
+				if imports[dir], err = importPath(dir); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			}
+			if imports[dir] == "" {
+				code = 1
+				continue
+			}
+			mopts = translate.MockOptions{Package: pkgName, Import: imports[dir]}
+			out = filepath.Join(outDir, strings.TrimSuffix(filepath.Base(translate.OutputName(file)), ".go")+"_mock.go")
+		}
+
+		r, err := s.GenerateMocks(file, mopts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+			continue
+		}
+		if r.Source == nil {
+			continue // the file has no classes
+		}
+		if old, _ := ioutil.ReadFile(out); !bytes.Equal(old, r.Source) && !writeOutput(out, r.Source) {
+			code = 1
+		}
+	}
+	return code
+}
+
+// importPath returns the import path of the package in dir, which the mocks in a separate package import.
+func importPath(dir string) (string, error) {
+	cmd := exec.Command("go", "list", "-find", "-f", "{{.ImportPath}}")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot find the import path of %s: %s", dir, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
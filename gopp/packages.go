@@ -0,0 +1,148 @@
+package main
+
+import (
+	"go/build"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spekary/gopp/translate"
+)
+
+// expandArgs returns the .gpp files named by the command line arguments. Like the go tool, each argument is a file, a
+// directory, whose .gpp files are used, or a directory followed by "/...", which also includes the .gpp files of all
+// of its subdirectories. Directories named testdata or vendor, or whose names begin with "." or "_", are skipped when
+// walking subdirectories. Files in directories are only used if they match the build constraints of the current
+// platform, the same way that .go files do.
+func expandArgs(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		if isPattern(arg) {
+			dirs, err := packageDirs(arg)
+			if err != nil {
+				return nil, err
+			}
+			for _, dir := range dirs {
+				files = append(files, dirFiles(dir)...)
+			}
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			files = append(files, dirFiles(arg)...)
+		} else {
+			files = append(files, arg)
+		}
+	}
+	return files, nil
+}
+
+// argDirs returns the directories named by the command line arguments, which are the directories whose .gpp files
+// expandArgs uses, along with the directories of the files that are named directly.
+func argDirs(args []string) ([]string, error) {
+	var dirs []string
+	for _, arg := range args {
+		if isPattern(arg) {
+			d, err := packageDirs(arg)
+			if err != nil {
+				return nil, err
+			}
+			dirs = append(dirs, d...)
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			dirs = append(dirs, arg)
+		} else {
+			dirs = append(dirs, filepath.Dir(arg))
+		}
+	}
+	return dirs, nil
+}
+
+// isPattern reports whether a command line argument is a directory followed by "/...".
+func isPattern(arg string) bool {
+	return arg == "..." || strings.HasSuffix(arg, "/...")
+}
+
+// packageDirs returns the directory of a pattern, like ./..., and all of its subdirectories, except for the ones named
+// testdata or vendor, or whose names begin with "." or "_".
+func packageDirs(pattern string) ([]string, error) {
+	root := strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+	if root == "" {
+		root = "."
+	}
+	var dirs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		name := info.Name()
+		if path != root && (name == "testdata" || name == "vendor" ||
+			strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	return dirs, err
+}
+
+// dirFiles returns the .gpp files in dir that match the build constraints of the current platform.
+func dirFiles(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.gpp"))
+	sort.Strings(matches)
+	var files []string
+	for _, file := range matches {
+		if matchFile(file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// matchFile reports whether a .gpp file matches the build constraints of the current platform, which come from its
+// name, like person_linux.gpp, and from //go:build lines before the package clause. It asks go/build about a .go file
+// of the same name, whose content is read from the .gpp file.
+func matchFile(file string) bool {
+	ctxt := build.Default
+	ctxt.OpenFile = func(path string) (io.ReadCloser, error) {
+		return os.Open(strings.TrimSuffix(path, ".go") + ".gpp")
+	}
+	dir, name := filepath.Split(file)
+	ok, err := ctxt.MatchFile(dir, strings.TrimSuffix(name, ".gpp")+".go")
+	return err == nil && ok
+}
+
+// outputPath returns the path of the .go file generated from a .gpp file. If outDir is given, the directories of the
+// .gpp file relative to the current directory are repeated under outDir, so that each package gets its own
+// directory. A file outside of the current directory is put directly in outDir.
+func outputPath(file string, outDir string) string {
+	out := translate.OutputName(file)
+	if outDir == "" {
+		return out
+	}
+	rel := out
+	if filepath.IsAbs(out) {
+		if wd, err := os.Getwd(); err == nil {
+			rel, _ = filepath.Rel(wd, out)
+		}
+	}
+	if rel == "" || filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = filepath.Base(out)
+	}
+	return filepath.Join(outDir, rel)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandArgs(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ignored := "//go:build ignore\n\npackage zoo\n"
+	writeFiles(t, ".", map[string]string{
+		"a.gpp":             "package zoo\n",
+		"ignored.gpp":       ignored,
+		"sub/b.gpp":         "package sub\n",
+		"sub/deep/c.gpp":    "package deep\n",
+		"sub/deep/d.gpp":    ignored,
+		"testdata/e.gpp":    "package testdata\n",
+		"vendor/f.gpp":      "package vendor\n",
+		"_skip/g.gpp":       "package skip\n",
+		".hidden/h.gpp":     "package hidden\n",
+		"sub/testdata/i.go": "package testdata\n",
+	})
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"./..."}, []string{"a.gpp", "sub/b.gpp", "sub/deep/c.gpp"}},
+		{[]string{"..."}, []string{"a.gpp", "sub/b.gpp", "sub/deep/c.gpp"}},
+		{[]string{"sub/..."}, []string{"sub/b.gpp", "sub/deep/c.gpp"}},
+		{[]string{"."}, []string{"a.gpp"}},
+		{[]string{"sub", "sub/deep"}, []string{"sub/b.gpp", "sub/deep/c.gpp"}},
+		// A file that is named is used even if it does not match the build constraints
+		{[]string{"ignored.gpp"}, []string{"ignored.gpp"}},
+		// A directory that is skipped by a pattern is used if it is named
+		{[]string{"testdata/..."}, []string{"testdata/e.gpp"}},
+	}
+	for _, test := range tests {
+		files, err := expandArgs(test.args)
+		if err != nil {
+			t.Errorf("%v: %v", test.args, err)
+			continue
+		}
+		var want []string
+		for _, file := range test.want {
+			want = append(want, filepath.FromSlash(file))
+		}
+		if !reflect.DeepEqual(files, want) {
+			t.Errorf("%v: got %v, want %v", test.args, files, want)
+		}
+	}
+
+	for _, args := range [][]string{{"missing.gpp"}, {"missing/..."}} {
+		if _, err := expandArgs(args); err == nil {
+			t.Errorf("%v: no error", args)
+		}
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(filepath.Dir(wd), "x", "c.gpp")
+
+	tests := []struct {
+		file   string
+		outDir string
+		want   string
+	}{
+		{"a/b.gpp", "", "a/b.go"},
+		{"a/b.gpp", "out", "out/a/b.go"},
+		{"b.gpp", "out", "out/b.go"},
+		{filepath.Join(wd, "a", "b.gpp"), "out", "out/a/b.go"},
+		{"../x/c.gpp", "out", "out/c.go"},
+		{outside, "out", "out/c.go"},
+		{outside, "", filepath.Join(filepath.Dir(wd), "x", "c.go")},
+	}
+	for _, test := range tests {
+		file := filepath.FromSlash(test.file)
+		if got := outputPath(file, test.outDir); got != filepath.FromSlash(test.want) {
+			t.Errorf("outputPath(%q, %q) = %q, want %q", file, test.outDir, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spekary/gopp/internal/diff"
+	"github.com/spekary/gopp/translate"
+)
+
+// verifyFiles generates the given .gpp files in memory and compares the code with the .go files that were generated
+// before, without writing any files. It prints a unified diff for each .go file that is not up to date, and reports
+// whether all of them are. A .go file that is missing is shown as added, and a file generated by gopp in one of dirs,
+// or in the output directory of one of dirs, whose .gpp file does not exist any more is shown as removed.
+func verifyFiles(files []string, dirs []string, gf genFlags, opts translate.Options) bool {
+	s := translate.NewSession(opts)
+	added := addFiles(s, files, gf)
+	ok := len(added) == len(files)
+
+	stale := 0
+	results, errs := s.GenerateAll(added, gf.jobs)
+	for i, file := range added {
+		if errs[i] != nil {
+			fmt.Fprintln(os.Stderr, errs[i])
+			ok = false
+			continue
+		}
+		out := outputPath(file, gf.outDir)
+		outputs := map[string][]byte{out: results[i].Source}
+		for suffix, src := range results[i].Files {
+			outputs[translate.SuffixName(out, suffix)] = src
+		}
+		var paths []string
+		for path := range outputs {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			old, err := ioutil.ReadFile(path)
+			oldName := path
+			if os.IsNotExist(err) {
+				oldName = os.DevNull
+			} else if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				ok = false
+				continue
+			}
+			if d := diff.Unified(oldName, path, old, outputs[path]); d != nil {
+				os.Stdout.Write(d)
+				stale++
+			}
+		}
+	}
+
+	removed, err := orphans(dirs, gf.outDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		ok = false
+	}
+	for _, out := range removed {
+		old, err := ioutil.ReadFile(out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			ok = false
+			continue
+		}
+		os.Stdout.Write(diff.Unified(out, os.DevNull, old, nil))
+		stale++
+	}
+
+	if stale > 0 {
+		fmt.Fprintf(os.Stderr, "gopp: generated files that are not up to date: %d\n", stale)
+	}
+	return ok && stale == 0
+}
+
+// orphans returns the .go files that gopp generated for .gpp files in dirs that do not exist any more. The .gpp files
+// that do not match the build constraints of the current platform still count, so that their .go files are kept. The
+// files that the file generators of the configuration of a directory write beside a generated file, and the mocks of
+// its classes, belong to its .gpp file too. Directories whose configuration cannot be read are skipped, and the error
+// is returned.
+func orphans(dirs []string, outDir string) ([]string, error) {
+	var errs []string
+	var out []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		// The output directory is found from the output path of a file in the directory
+		gen := filepath.Dir(outputPath(filepath.Join(dir, "x.gpp"), outDir))
+		if seen[gen] {
+			continue
+		}
+		seen[gen] = true
+
+		config, _, err := translate.LoadConfig(dir)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		var suffixes []string
+		for _, g := range config.Generators {
+			if g.File {
+				suffixes = append(suffixes, g.Name)
+			}
+		}
+
+		expected := make(map[string]bool)
+		gpps, _ := filepath.Glob(filepath.Join(dir, "*.gpp"))
+		for _, file := range gpps {
+			expected[outputPath(file, outDir)] = true
+		}
+		gos, _ := filepath.Glob(filepath.Join(gen, "*.go"))
+		for _, file := range gos {
+			mocked := strings.TrimSuffix(file, "_mock_test.go") + ".go"
+			if expected[file] || expected[mocked] || expected[generatorOutput(file, suffixes)] {
+				continue
+			}
+			if src, err := ioutil.ReadFile(file); err == nil && translate.IsGenerated(src) {
+				out = append(out, file)
+			}
+		}
+	}
+	sort.Strings(out)
+	if errs != nil {
+		return out, errors.New(strings.Join(errs, "\n"))
+	}
+	return out, nil
+}
+
+// generatorOutput returns the generated file that a file written beside it by a file generator with one of the given
+// suffixes belongs to, like person.go for person_db.go, or an empty string if the file does not end with any of them.
+func generatorOutput(file string, suffixes []string) string {
+	for _, suffix := range suffixes {
+		if end := "_" + suffix + ".go"; strings.HasSuffix(file, end) {
+			return strings.TrimSuffix(file, end) + ".go"
+		}
+	}
+	return ""
+}
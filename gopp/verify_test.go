@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const generatedSrc = "// Code generated by gopp. DO NOT EDIT.\n\npackage zoo\n"
+
+func TestOrphans(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		outDir string
+		want   []string
+	}{
+		{
+			name:  "generated file of a .gpp file",
+			files: map[string]string{"person.gpp": "package zoo\n", "person.go": generatedSrc},
+		},
+		{
+			name:  "deleted .gpp file",
+			files: map[string]string{"person.go": generatedSrc},
+			want:  []string{"person.go"},
+		},
+		{
+			name:  "file that was not generated",
+			files: map[string]string{"person.go": "package zoo\n"},
+		},
+		{
+			name: "deleted .gpp file whose name starts with another",
+			files: map[string]string{
+				"person.gpp": "package zoo\n", "person.go": generatedSrc, "person_v2.go": generatedSrc,
+			},
+			want: []string{"person_v2.go"},
+		},
+		{
+			name: "file of a file generator",
+			files: map[string]string{
+				"gopp.toml":  "[file_generators]\ndb = \"gopp-db\"\n",
+				"person.gpp": "package zoo\n", "person.go": generatedSrc, "person_db.go": generatedSrc,
+				"person_v2.go": generatedSrc, "place_db.go": generatedSrc,
+			},
+			want: []string{"person_v2.go", "place_db.go"},
+		},
+		{
+			name: "mocks",
+			files: map[string]string{
+				"person.gpp": "package zoo\n", "person.go": generatedSrc, "person_mock_test.go": generatedSrc,
+				"place_mock_test.go": generatedSrc,
+			},
+			want: []string{"place_mock_test.go"},
+		},
+		{
+			name:  "header of earlier versions",
+			files: map[string]string{"person.go": "//** This file is code generated by gopp. Do not edit.\n\npackage zoo\n"},
+			want:  []string{"person.go"},
+		},
+		{
+			name: ".gpp file for another platform",
+			files: map[string]string{
+				"person.gpp": "//go:build ignore\n\npackage zoo\n", "person.go": generatedSrc,
+			},
+		},
+		{
+			name: "output directory",
+			files: map[string]string{
+				"person.gpp": "package zoo\n", "out/person.go": generatedSrc, "out/place.go": generatedSrc,
+				"place.go": generatedSrc,
+			},
+			outDir: "out",
+			want:   []string{"out/place.go"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			writeFiles(t, ".", test.files)
+			got, err := orphans([]string{"."}, test.outDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, file := range test.want {
+				want = append(want, filepath.FromSlash(file))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestOrphansBadConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFiles(t, ".", map[string]string{"gopp.toml": "[file_generators]\ndb = \"\"\n", "person_db.go": generatedSrc})
+	if got, err := orphans([]string{"."}, ""); err == nil || got != nil {
+		t.Errorf("got %v, %v, want an error", got, err)
+	}
+}
+
+func TestClean(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFiles(t, ".", map[string]string{
+		"person.gpp": "package zoo\n", "person.go": generatedSrc, "place.go": generatedSrc, "sub/thing.go": generatedSrc,
+	})
+
+	// A dry run removes nothing
+	if code := runClean([]string{"-n", "./..."}); code != 0 {
+		t.Errorf("Exit code %d", code)
+	}
+	for _, name := range []string{"person.go", "place.go", "sub/thing.go"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("Dry run removed %s", name)
+		}
+	}
+
+	if code := runClean([]string{"./..."}); code != 0 {
+		t.Errorf("Exit code %d", code)
+	}
+	for name, want := range map[string]bool{"person.go": true, "place.go": false, "sub/thing.go": false} {
+		if _, err := os.Stat(name); (err == nil) != want {
+			t.Errorf("%s kept is %v, want %v", name, err == nil, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spekary/gopp/translate"
+)
+
+// watchInterval is how often the watched directories are scanned for changes.
+const watchInterval = 500 * time.Millisecond
+
+// fileStamp is what is known about a file to tell whether it changed.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watch watches the .gpp files named by the given paths, and generates a file again whenever it changes, along with
+// the files that declare subclasses of its classes. Problems are printed as they are found, and watching goes on until
+// the process is stopped. Up to gf.jobs files are parsed and generated at the same time.
+func watch(paths []string, gf genFlags, opts translate.Options) {
+	s := translate.NewSession(opts)
+	known := make(map[string]fileStamp)
+
+	fmt.Println("Watching .gpp files for changes. Press Ctrl+C to stop.")
+	for {
+		watchStep(s, paths, known, gf)
+		time.Sleep(watchInterval)
+	}
+}
+
+// watchStep scans the files named by paths once, and generates the files that were added or changed since the last
+// scan, along with the files that depend on them or on the files that were removed. The files generated for the
+// removed files are removed too. It returns the files that it generated, in order.
+func watchStep(s *translate.Session, paths []string, known map[string]fileStamp, gf genFlags) []string {
+	changed, removed := scanFiles(paths, known)
+	regenerate := make(map[string]bool)
+
+	for _, file := range removed {
+		for _, dep := range s.Dependents(file) {
+			regenerate[dep] = true
+		}
+		removeOutput(s, file, gf.outDir)
+		s.Remove(file)
+	}
+	// All of the changed files are added first, so that they can refer to each other's classes
+	before := make(map[string][]string)
+	for _, file := range changed {
+		before[file] = s.Dependents(file)
+	}
+	// The dependents of a file with errors keep its previous version, so they do not need to change
+	for _, file := range addFiles(s, changed, gf) {
+		regenerate[file] = true
+		for _, dep := range append(before[file], s.Dependents(file)...) {
+			regenerate[dep] = true
+		}
+	}
+
+	var files []string
+	for file := range regenerate {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		fmt.Println("Generating " + file)
+	}
+	generateFiles(s, files, gf)
+	return files
+}
+
+// removeOutput removes the .go file generated for a .gpp file that was removed, along with the files that generators
+// wrote beside it. Files that were not generated by gopp are kept.
+func removeOutput(s *translate.Session, file string, outDir string) {
+	out := outputPath(file, outDir)
+	paths := []string{out}
+	for _, suffix := range s.FileSuffixes(file) {
+		paths = append(paths, translate.SuffixName(out, suffix))
+	}
+	for _, path := range paths {
+		if src, err := ioutil.ReadFile(path); err != nil || !translate.IsGenerated(src) {
+			continue
+		}
+		fmt.Println("Removing " + path)
+		if err := os.Remove(path); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// scanFiles finds the .gpp files named by paths that were added or changed since the last scan, and the ones that
+// were removed. The stamps of the files are updated in known.
+func scanFiles(paths []string, known map[string]fileStamp) (changed []string, removed []string) {
+	found := make(map[string]bool)
+	files, err := expandArgs(paths)
+	if err != nil {
+		fmt.Println(err)
+		// Keep the files that are known, since the paths can come back
+		return nil, nil
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		found[file] = true
+		stamp := fileStamp{info.ModTime(), info.Size()}
+		if prev, ok := known[file]; !ok || prev != stamp {
+			known[file] = stamp
+			changed = append(changed, file)
+		}
+	}
+	for file := range known {
+		if !found[file] {
+			delete(known, file)
+			removed = append(removed, file)
+		}
+	}
+	sort.Strings(removed)
+	return
+}
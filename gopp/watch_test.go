@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spekary/gopp/translate"
+)
+
+func TestWatchStep(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFiles(t, ".", map[string]string{"animal.gpp": animalSrc, "dog.gpp": dogSrc, "cat.gpp": "package zoo\n"})
+	s := translate.NewSession(translate.Options{})
+	known := make(map[string]fileStamp)
+	gf := genFlags{jobs: 1}
+	paths := []string{"."}
+
+	if got := watchStep(s, paths, known, gf); !reflect.DeepEqual(got, []string{"animal.gpp", "cat.gpp", "dog.gpp"}) {
+		t.Errorf("First scan generated %v", got)
+	}
+	if got := watchStep(s, paths, known, gf); got != nil {
+		t.Errorf("Unchanged files generated %v", got)
+	}
+
+	// A change to a superclass generates its subclasses again
+	writeFiles(t, ".", map[string]string{"animal.gpp": strings.Replace(animalSrc, "name string", "name string\n\tage int", 1)})
+	touch(t, "animal.gpp")
+	if got := watchStep(s, paths, known, gf); !reflect.DeepEqual(got, []string{"animal.gpp", "dog.gpp"}) {
+		t.Errorf("Changed superclass generated %v", got)
+	}
+	if src := readFile("animal.go"); !strings.Contains(src, "age") {
+		t.Errorf("animal.go not generated again:\n%s", src)
+	}
+
+	// Removing a superclass generates its subclasses again, and removes its generated file. The subclass then extends a
+	// type that gopp does not know, which could be declared in Go code of the package, so it is not an error.
+	writeFiles(t, ".", map[string]string{"cat.go": "package zoo\n"})
+	for _, name := range []string{"animal.gpp", "cat.gpp"} {
+		if err := os.Remove(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := watchStep(s, paths, known, gf); !reflect.DeepEqual(got, []string{"dog.gpp"}) {
+		t.Errorf("Removed superclass generated %v", got)
+	}
+	if _, err := os.Stat("animal.go"); err == nil {
+		t.Error("The generated file of a removed .gpp file was kept")
+	}
+	// A file that was not generated is kept
+	if _, err := os.Stat("cat.go"); err != nil {
+		t.Error("A file that was not generated was removed")
+	}
+}
+
+// touch changes the modification time of a file, so that a scan finds it changed even if it was written in the same
+// tick of the clock.
+func touch(t *testing.T, name string) {
+	t.Helper()
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(name, future, future); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Package diff computes the differences between two texts, line by line, and reports them as a unified diff.
+*/
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+// op is one step of an edit script that turns the old lines into the new lines.
+type op struct {
+	kind byte // ' ' for a line in both, '-' for a deleted line, '+' for an inserted line
+	a, b int  // the index of the line in the old and new lines
+}
+
+// Unified returns the unified diff that turns old into new, using oldName and newName as the names of the texts in
+// the header. It returns nil if the texts are the same.
+func Unified(oldName, newName string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	a, b := lines(old), lines(new)
+	ops := edits(a, b)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+
+	for i := 0; i < len(ops); {
+		// find the next change
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		// extend the hunk until there are more than 2*context unchanged lines in a row
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		stop := end + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+		writeHunk(&buf, a, b, ops[start:stop])
+		i = stop
+	}
+	return buf.Bytes()
+}
+
+func writeHunk(buf *bytes.Buffer, a, b []string, ops []op) {
+	var aStart, aLen, bStart, bLen int
+	aStart, bStart = -1, -1
+	for _, o := range ops {
+		if o.kind != '+' {
+			if aStart < 0 {
+				aStart = o.a
+			}
+			aLen++
+		}
+		if o.kind != '-' {
+			if bStart < 0 {
+				bStart = o.b
+			}
+			bLen++
+		}
+	}
+	// An empty range is reported as starting at the line before it
+	if aStart < 0 {
+		aStart = ops[0].a - 1
+	}
+	if bStart < 0 {
+		bStart = ops[0].b - 1
+	}
+	fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+	for _, o := range ops {
+		var line string
+		if o.kind == '+' {
+			line = b[o.b]
+		} else {
+			line = a[o.a]
+		}
+		buf.WriteByte(o.kind)
+		buf.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, n int) string {
+	if n == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// lines splits text into lines, keeping the newlines.
+func lines(text []byte) []string {
+	var out []string
+	for s := string(text); s != ""; {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			out = append(out, s)
+			break
+		}
+		out = append(out, s[:i+1])
+		s = s[i+1:]
+	}
+	return out
+}
+
+// edits returns the shortest edit script that turns a into b, using the algorithm of Myers.
+func edits(a, b []string) []op {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m, off)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack follows the trace of the search for the shortest edit script from the end to the start, and returns the
+// script.
+func backtrack(trace [][]int, x, y int, off int) []op {
+	var ops []op
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{' ', x, y})
+		}
+		if x == prevX {
+			ops = append(ops, op{'+', x, prevY})
+		} else {
+			ops = append(ops, op{'-', prevX, y})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{' ', x, y})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,37 @@
+package diff
+
+import (
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	new := "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\nl"
+	want := `--- old
++++ new
+@@ -1,7 +1,7 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+@@ -9,3 +9,4 @@
+ i
+ j
+ k
++l
+\ No newline at end of file
+`
+	if got := string(Unified("old", "new", []byte(old), []byte(new))); got != want {
+		t.Errorf("Wrong diff:\n%s", got)
+	}
+	if Unified("old", "new", []byte(old), []byte(old)) != nil {
+		t.Error("Diff of the same texts is not empty")
+	}
+	if got := string(Unified("old", "new", nil, []byte("a\n"))); got != "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n" {
+		t.Errorf("Wrong diff of new file:\n%s", got)
+	}
+}
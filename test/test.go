@@ -1,4 +1,5 @@
-//** This file is code generated by gopp. Do not edit.
+// Code generated by gopp. DO NOT EDIT.
+//gopp:hash 66b29fc54e7a06d77c2d6d706f93136a1a7021d10c557847053c4d53d4ca7142
 
 package test
 
@@ -27,6 +28,7 @@ type Test struct {
 func NewTest(me int) TestI {
 	t_ := Test{}
 	t_.Init(&t_)
+	t_.InitDefaults()
 	t_.Construct(me)
 	return t_.I().(TestI)
 }
@@ -48,17 +50,49 @@ func (t_ *Test) My3() {
 
 }
 
-func (t_ *Test) IsA(className string) bool {
-	if className == "Test" {
+func (t_ *Test) IsA(gopp_className string) bool {
+	if gopp_className == "Test" {
 		return true
 	}
-	return t_.Base.IsA(className)
+	return t_.Base.IsA(gopp_className)
 }
 
 func (t_ *Test) Class() string {
 	return "Test"
 }
 
+func (t_ *Test) asTest_() *Test {
+	return t_
+}
+
+// IsTest returns true if obj is a Test or a subclass of Test.
+func IsTest(obj gopp.BaseI) bool {
+	_, ok := AsTest(obj)
+	return ok
+}
+
+// AsTest returns obj as a TestI if it is a Test or a subclass of Test.
+func AsTest(obj gopp.BaseI) (TestI, bool) {
+	if _, ok := gopp.As[interface{ asTest_() *Test }](obj); !ok {
+		return nil, false
+	}
+	return gopp.As[TestI](obj)
+}
+
+// Clone returns a copy of the object that is initialized so that virtual calls on it go to the copy.
+func (t_ *Test) Clone() gopp.BaseI {
+	gopp_c := new(Test)
+	gopp_c.Init(gopp_c)
+	t_.CloneMembers(gopp_c)
+	return gopp_c.I()
+}
+
+// CloneMembers copies the members of Test and its superclasses into gopp_c. Slices and maps are copied, other members are assigned.
+func (t_ *Test) CloneMembers(gopp_c *Test) {
+	t_.Base.CloneMembers(&gopp_c.Base)
+	gopp_c.me = t_.me
+}
+
 type AI interface {
 	TestI
 
@@ -73,6 +107,7 @@ type A struct {
 func NewA() AI {
 	a_ := A{}
 	a_.Init(&a_)
+	a_.InitDefaults()
 	a_.Construct()
 	return a_.I().(AI)
 }
@@ -86,17 +121,48 @@ func (a_ *A) Oh() {
 	a_.Test.My3()
 }
 
-func (a_ *A) IsA(className string) bool {
-	if className == "A" {
+func (a_ *A) IsA(gopp_className string) bool {
+	if gopp_className == "A" {
 		return true
 	}
-	return a_.Test.IsA(className)
+	return a_.Test.IsA(gopp_className)
 }
 
 func (a_ *A) Class() string {
 	return "A"
 }
 
+func (a_ *A) asA_() *A {
+	return a_
+}
+
+// IsA returns true if obj is a A or a subclass of A.
+func IsA(obj gopp.BaseI) bool {
+	_, ok := AsA(obj)
+	return ok
+}
+
+// AsA returns obj as a AI if it is a A or a subclass of A.
+func AsA(obj gopp.BaseI) (AI, bool) {
+	if _, ok := gopp.As[interface{ asA_() *A }](obj); !ok {
+		return nil, false
+	}
+	return gopp.As[AI](obj)
+}
+
+// Clone returns a copy of the object that is initialized so that virtual calls on it go to the copy.
+func (a_ *A) Clone() gopp.BaseI {
+	gopp_c := new(A)
+	gopp_c.Init(gopp_c)
+	a_.CloneMembers(gopp_c)
+	return gopp_c.I()
+}
+
+// CloneMembers copies the members of A and its superclasses into gopp_c. Slices and maps are copied, other members are assigned.
+func (a_ *A) CloneMembers(gopp_c *A) {
+	a_.Test.CloneMembers(&gopp_c.Test)
+}
+
 /*
 class Test2<T> extends gopp.Base {
 	me <T>
@@ -1,4 +1,5 @@
-//** This file is code generated by gopp. Do not edit.
+// Code generated by gopp. DO NOT EDIT.
+//gopp:hash f111770bef21503dc411c0a50cb55d349e1fb4474e05d80b8c1ae603caca2452
 
 package test
 
@@ -22,6 +23,7 @@ type Thing struct {
 func NewThing() ThingI {
 	t_ := Thing{}
 	t_.Init(&t_)
+	t_.InitDefaults()
 	t_.Construct()
 	return t_.I().(ThingI)
 }
@@ -38,17 +40,48 @@ func (t_ *Thing) Name() string {
 	return "No Name"
 }
 
-func (t_ *Thing) IsA(className string) bool {
-	if className == "Thing" {
+func (t_ *Thing) IsA(gopp_className string) bool {
+	if gopp_className == "Thing" {
 		return true
 	}
-	return t_.Base.IsA(className)
+	return t_.Base.IsA(gopp_className)
 }
 
 func (t_ *Thing) Class() string {
 	return "Thing"
 }
 
+func (t_ *Thing) asThing_() *Thing {
+	return t_
+}
+
+// IsThing returns true if obj is a Thing or a subclass of Thing.
+func IsThing(obj gopp.BaseI) bool {
+	_, ok := AsThing(obj)
+	return ok
+}
+
+// AsThing returns obj as a ThingI if it is a Thing or a subclass of Thing.
+func AsThing(obj gopp.BaseI) (ThingI, bool) {
+	if _, ok := gopp.As[interface{ asThing_() *Thing }](obj); !ok {
+		return nil, false
+	}
+	return gopp.As[ThingI](obj)
+}
+
+// Clone returns a copy of the object that is initialized so that virtual calls on it go to the copy.
+func (t_ *Thing) Clone() gopp.BaseI {
+	gopp_c := new(Thing)
+	gopp_c.Init(gopp_c)
+	t_.CloneMembers(gopp_c)
+	return gopp_c.I()
+}
+
+// CloneMembers copies the members of Thing and its superclasses into gopp_c. Slices and maps are copied, other members are assigned.
+func (t_ *Thing) CloneMembers(gopp_c *Thing) {
+	t_.Base.CloneMembers(&gopp_c.Base)
+}
+
 type PersonI interface {
 	ThingI
 
@@ -68,6 +101,7 @@ type Person struct {
 func NewPerson(first string, last string) PersonI {
 	p_ := Person{}
 	p_.Init(&p_)
+	p_.InitDefaults()
 	p_.Construct(first, last)
 	return p_.I().(PersonI)
 }
@@ -105,13 +139,46 @@ func (p_ *Person) MapReturn() map[string]Thing {
 	return a
 }
 
-func (p_ *Person) IsA(className string) bool {
-	if className == "Person" {
+func (p_ *Person) IsA(gopp_className string) bool {
+	if gopp_className == "Person" {
 		return true
 	}
-	return p_.Thing.IsA(className)
+	return p_.Thing.IsA(gopp_className)
 }
 
 func (p_ *Person) Class() string {
 	return "Person"
 }
+
+func (p_ *Person) asPerson_() *Person {
+	return p_
+}
+
+// IsPerson returns true if obj is a Person or a subclass of Person.
+func IsPerson(obj gopp.BaseI) bool {
+	_, ok := AsPerson(obj)
+	return ok
+}
+
+// AsPerson returns obj as a PersonI if it is a Person or a subclass of Person.
+func AsPerson(obj gopp.BaseI) (PersonI, bool) {
+	if _, ok := gopp.As[interface{ asPerson_() *Person }](obj); !ok {
+		return nil, false
+	}
+	return gopp.As[PersonI](obj)
+}
+
+// Clone returns a copy of the object that is initialized so that virtual calls on it go to the copy.
+func (p_ *Person) Clone() gopp.BaseI {
+	gopp_c := new(Person)
+	gopp_c.Init(gopp_c)
+	p_.CloneMembers(gopp_c)
+	return gopp_c.I()
+}
+
+// CloneMembers copies the members of Person and its superclasses into gopp_c. Slices and maps are copied, other members are assigned.
+func (p_ *Person) CloneMembers(gopp_c *Person) {
+	p_.Thing.CloneMembers(&gopp_c.Thing)
+	gopp_c.first = p_.first
+	gopp_c.last = p_.last
+}
@@ -0,0 +1,188 @@
+package translate
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Position is a position in a .gpp file.
+type Position struct {
+	Filename string
+	Offset   int // the byte offset, starting at 0
+	Line     int // the line, starting at 1
+	Column   int // the byte offset in the line, starting at 1
+}
+
+// IsValid returns true if the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return s
+}
+
+// Node is a node in the AST of a .gpp file.
+type Node interface {
+	Pos() Position
+}
+
+// File is the AST of a .gpp file.
+type File struct {
+	Name    string // the name of the file
+	Package string // the name of the package
+	Decls   []Decl
+}
+
+// Decl is a top level declaration in a file, which is either a *GoDecl or a *ClassDecl.
+type Decl interface {
+	Node
+	declNode()
+}
+
+// GoDecl is Go source code outside of a class, which is passed through to the generated file without changes. It
+// can hold any Go code, including the package clause, imports, comments and declarations.
+type GoDecl struct {
+	Position Position
+	Text     string
+}
+
+// ClassDecl is the declaration of a class.
+type ClassDecl struct {
+	Position       Position // the position of the class name
+	Comment        string   // the comment before the class
+	Name           string
+	Extends        string   // the superclass, which is qualified with a package name if it is in a different package
+	Modifiers      []string // the modifiers after the superclass, like "equatable"
+	Members        []*MemberDecl
+	Methods        []*MethodDecl
+	ClosingComment string // comments at the end of the class body that are not attached to a member or method
+}
+
+// MemberDecl is the declaration of a member variable in a class.
+type MemberDecl struct {
+	Position    Position
+	CommentPos  Position // the position of the comment before the member
+	Comment     string   // the comment before the member
+	Names       []string // the names of the variables, which is empty if the member is an embedded type
+	Type        string
+	Tag         string // the struct tag, including tags created from annotations, without the quotes
+	RawTag      string // the struct tag as it is written in the source, without the quotes
+	Annotations []Annotation
+	Default     string // the expression that gives the default value of the variables, if any
+	LineComment string // the comment after the member on the same line
+
+	end Position
+}
+
+// MethodDecl is the declaration of a method in a class.
+type MethodDecl struct {
+	Position   Position
+	CommentPos Position // the position of the comment before the method
+	Comment    string   // the comment before the method
+	Modifiers  []string // the keywords before "func", like "override"
+	Name       string
+	Params     string // the parameters and results, like "(a int) string"
+	Body       string // the body, including the braces
+
+	end Position
+}
+
+func (f *File) Pos() Position       { return Position{Filename: f.Name, Line: 1, Column: 1} }
+func (d *GoDecl) Pos() Position     { return d.Position }
+func (c *ClassDecl) Pos() Position  { return c.Position }
+func (m *MemberDecl) Pos() Position { return m.Position }
+func (m *MethodDecl) Pos() Position { return m.Position }
+
+// End returns the position just after the member, which is not valid if the member was not parsed.
+func (m *MemberDecl) End() Position { return m.end }
+
+// End returns the position just after the method, which is not valid if the method was not parsed.
+func (m *MethodDecl) End() Position { return m.end }
+
+func (*GoDecl) declNode()    {}
+func (*ClassDecl) declNode() {}
+
+// HasModifier returns true if the class has the given modifier.
+func (c *ClassDecl) HasModifier(mod string) bool {
+	return hasString(c.Modifiers, mod)
+}
+
+// Elems returns the members and methods of the class in the order they are declared.
+func (c *ClassDecl) Elems() []Node {
+	var elems []Node
+	for _, m := range c.Members {
+		elems = append(elems, m)
+	}
+	for _, m := range c.Methods {
+		elems = append(elems, m)
+	}
+	sort.SliceStable(elems, func(i, j int) bool {
+		return elems[i].Pos().Offset < elems[j].Pos().Offset
+	})
+	return elems
+}
+
+// IsOverride returns true if the method overrides a method of a superclass.
+func (m *MethodDecl) IsOverride() bool {
+	return hasString(m.Modifiers, tokOverride)
+}
+
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// A Visitor's Visit method is called for each node found by Walk. If the result visitor w is not nil, Walk visits
+// each of the children of node with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order. It starts by calling v.Visit(node), and then walks the children of
+// node with the visitor returned by v.Visit, unless it is nil. The members and methods of a class are visited in the
+// order they are declared.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *File:
+		for _, d := range n.Decls {
+			Walk(v, d)
+		}
+	case *ClassDecl:
+		for _, e := range n.Elems() {
+			Walk(v, e)
+		}
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order. It starts by calling f(node), and if f returns true, Inspect calls
+// itself for each of the children of node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
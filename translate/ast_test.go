@@ -0,0 +1,72 @@
+package translate
+
+import (
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	src := `// Copyright
+package test
+
+/* A shape */
+class Shape extends gopp.Base equatable {
+	name string @readonly
+
+	override func String() string {
+		return this.name
+	}
+	x, y int = 1, 2
+	// the end
+}
+`
+	f, err := ParseFile("shape.gpp", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "shape.gpp" || f.Package != "test" || len(f.Decls) != 3 {
+		t.Fatalf("Wrong file: %+v", f)
+	}
+	if d, ok := f.Decls[0].(*GoDecl); !ok || d.Text != "// Copyright\npackage test\n\n/* A shape */\n" {
+		t.Errorf("Wrong go decl: %#v", f.Decls[0])
+	}
+	c, ok := f.Decls[1].(*ClassDecl)
+	if !ok {
+		t.Fatalf("Expected a class, got %#v", f.Decls[1])
+	}
+	if c.Name != "Shape" || c.Extends != "gopp.Base" || !c.HasModifier("equatable") ||
+		c.Position.Line != 5 || c.Position.Column != 7 || c.ClosingComment != "// the end" {
+		t.Errorf("Wrong class: %+v", c)
+	}
+	if len(c.Methods) != 1 || !c.Methods[0].IsOverride() || c.Methods[0].Params != "() string" ||
+		c.Methods[0].Position.Line != 8 {
+		t.Errorf("Wrong methods: %+v", c.Methods)
+	}
+	if len(c.Members) != 2 || !c.Members[0].ReadOnly() || c.Members[1].Default != "1, 2" ||
+		c.Members[1].Position.Line != 11 || c.Members[1].Position.Column != 2 {
+		t.Errorf("Wrong members: %+v", c.Members)
+	}
+
+	var visited []string
+	Inspect(f, func(n Node) bool {
+		switch n := n.(type) {
+		case *ClassDecl:
+			visited = append(visited, n.Name)
+		case *MemberDecl:
+			visited = append(visited, n.Names[0])
+		case *MethodDecl:
+			visited = append(visited, n.Name)
+		}
+		return true
+	})
+	if got := len(visited); got != 4 || visited[1] != "name" || visited[2] != "String" || visited[3] != "x" {
+		t.Errorf("Wrong nodes visited: %v", visited)
+	}
+}
+
+func TestParseFileError(t *testing.T) {
+	_, err := ParseFile("bad.gpp", []byte("package test\n\nclass A extends B {\n\tname string `json:a`\n}\n"))
+	diags, ok := err.(Diagnostics)
+	if !ok || len(diags) != 1 || diags[0].Line != 4 || diags[0].Column != 2 {
+		t.Errorf("Wrong error position: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package translate
+
+import (
+	goast "go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Check checks the files that were added to the session without generating any files. Besides the problems that
+// Generate reports, it checks that a method is marked override exactly when it overrides a method of a superclass,
+// and that the overriding method has the same signature. The generated code of each package is type checked together
+// with the other .go files in the directory of the package, importing the real dependencies of the package. Problems
+// found in the generated code are reported at the lines of the .gpp files they come from. The Diagnostics are sorted
+// by file and line.
+func (s *Session) Check() Diagnostics {
+	var diags Diagnostics
+	for _, scope := range s.scopes {
+		diags = append(diags, s.checkPackage(scope)...)
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].File != diags[j].File {
+			return diags[i].File < diags[j].File
+		}
+		return diags[i].Line < diags[j].Line
+	})
+	return diags
+}
+
+func (s *Session) checkPackage(scope *pkgScope) Diagnostics {
+	pkg, diags := s.typeCheck(scope)
+	if pkg == nil {
+		return diags
+	}
+	for _, name := range scope.files {
+		for _, c := range s.files[name].classes() {
+			diags = append(diags, checkOverrides(pkg, c)...)
+		}
+	}
+	return diags
+}
+
+// typeCheck type checks the generated code of a package together with the other .go files in its directory. It returns
+// nil if code could not be generated for all of the files of the package.
+func (s *Session) typeCheck(scope *pkgScope) (pkg *types.Package, diags Diagnostics) {
+	fset := token.NewFileSet()
+	var files []*goast.File
+	lines := make(map[string]lineMap)
+	generated := make(map[string]bool)
+
+	for _, name := range scope.files {
+		generated[OutputName(name)] = true
+		code, m, err := s.generate(s.files[name])
+		if err != nil {
+			diags = append(diags, diagnostic(name, err))
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, generatedHeader+code, parser.ParseComments)
+		if err != nil {
+			diags = append(diags, codeDiagnostics(name, err, m)...)
+			continue
+		}
+		files = append(files, f)
+		lines[name] = m
+	}
+	if len(diags) > 0 {
+		return nil, diags
+	}
+
+	// The Go files of the package that are not generated from the files that are checked
+	dir := scope.dir
+	if dir == "" {
+		dir = "."
+	}
+	infos, _ := ioutil.ReadDir(dir)
+	for _, info := range infos {
+		name := filepath.Join(dir, info.Name())
+		if info.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || generated[filepath.Clean(name)] {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, info.Name()); err != nil || !ok {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			diags = append(diags, Diagnostic{File: name, Message: err.Error()})
+			continue
+		}
+		if f.Name.Name == scope.name {
+			files = append(files, f)
+		}
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			if e, ok := err.(types.Error); ok {
+				diags = append(diags, typeDiagnostic(e, lines))
+			} else {
+				diags = append(diags, Diagnostic{Message: err.Error()})
+			}
+		},
+	}
+	pkg, _ = conf.Check(scope.name, fset, files, nil)
+	return pkg, diags
+}
+
+// typeDiagnostic returns the Diagnostic of an error found by the type checker. Errors in generated code are mapped
+// back to the .gpp files.
+func typeDiagnostic(e types.Error, lines map[string]lineMap) Diagnostic {
+	pos := e.Fset.Position(e.Pos)
+	if m, ok := lines[pos.Filename]; ok {
+		return Diagnostic{File: pos.Filename, Line: m.fileLine(pos.Line), Message: e.Msg}
+	}
+	return Diagnostic{File: pos.Filename, Line: pos.Line, Column: pos.Column, Message: e.Msg}
+}
+
+// checkOverrides checks that the methods of a class that override a method of a superclass, and only those, are
+// marked override, and that they have the same signature as the method they override. The constructor is not
+// checked, since its parameters can be different.
+func checkOverrides(pkg *types.Package, c *classDef) (diags Diagnostics) {
+	tn, ok := pkg.Scope().Lookup(c.Struct).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	st, ok := tn.Type().Underlying().(*types.Struct)
+	if !ok || st.NumFields() == 0 || !st.Field(0).Embedded() {
+		return nil
+	}
+	parent := types.NewPointer(st.Field(0).Type())
+
+	for _, m := range c.decl.Methods {
+		if m.Name == "Construct" {
+			continue
+		}
+		obj, _, _ := types.LookupFieldOrMethod(parent, true, pkg, m.Name)
+		super, isMethod := obj.(*types.Func)
+		switch {
+		case m.IsOverride() && !isMethod:
+			diags = append(diags, diagnostic(c.file, posErrorf(m.Position,
+				"method %s is marked override, but %s has no method %s", m.Name, c.Extends, m.Name)))
+		case !m.IsOverride() && isMethod:
+			diags = append(diags, diagnostic(c.file, posErrorf(m.Position,
+				"method %s overrides the method of %s, so it must be marked override", m.Name, c.Extends)))
+		case isMethod:
+			obj, _, _ = types.LookupFieldOrMethod(types.NewPointer(tn.Type()), true, pkg, m.Name)
+			if own, ok := obj.(*types.Func); ok && !types.Identical(own.Type(), super.Type()) {
+				diags = append(diags, diagnostic(c.file, posErrorf(m.Position,
+					"method %s has signature %s, but the method it overrides has signature %s",
+					m.Name, signature(own), signature(super))))
+			}
+		}
+	}
+	return diags
+}
+
+// signature returns the signature of a method without its receiver, relative to the package of the method.
+func signature(f *types.Func) string {
+	sig := f.Type().(*types.Signature)
+	s := types.TypeString(types.NewSignatureType(nil, nil, nil, sig.Params(), sig.Results(), sig.Variadic()),
+		types.RelativeTo(f.Pkg()))
+	return strings.TrimPrefix(s, "func")
+}
+
+// OutputName returns the name of the .go file that is generated from a .gpp file, which is the name of the .gpp file
+// with a .go extension.
+func OutputName(file string) string {
+	if i := strings.LastIndex(file, "."); i >= 0 && !strings.ContainsAny(file[i:], `/\`) {
+		file = file[:i]
+	}
+	return filepath.Clean(file + ".go")
+}
@@ -0,0 +1,52 @@
+package translate
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	src := `package test
+
+class Animal extends gopp.Base {
+	func Sound() string {
+		return "..."
+	}
+}
+
+class Dog extends Animal {
+	func Sound() string {
+		return "woof"
+	}
+
+	override func Run() {
+	}
+}
+
+class Cat extends Animal {
+	override func Sound() int {
+		return this.lives
+	}
+}
+`
+	file := filepath.Join(t.TempDir(), "animal.gpp")
+	s := NewSession(Options{})
+	if err := s.Add(file, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{
+		10: "must be marked override",
+		14: "Animal has no method Run",
+		19: "signature () int",
+		20: "lives undefined",
+	}
+	for _, d := range s.Check() {
+		if msg, ok := want[d.Line]; ok && d.File == file && strings.Contains(d.Message, msg) {
+			delete(want, d.Line)
+		}
+	}
+	for line, msg := range want {
+		t.Errorf("Missing diagnostic at line %d: %s", line, msg)
+	}
+}
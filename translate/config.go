@@ -0,0 +1,248 @@
+package translate
+
+import (
+	"errors"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigName is the name of the configuration file of a project.
+const ConfigName = "gopp.toml"
+
+// Config is the configuration of a project, which is read from a gopp.toml file. The file is a small subset of TOML,
+// with tables and string values:
+//
+//	# The interface gets the name of the class, and the struct is not exported
+//	[naming]
+//	interface = "{Name}"
+//	struct = "{name}"
+//	constructor = "New{Name}"
+//	receiver = "{n}"
+//
+//	# The directory of templates that replace the built in templates, relative to the gopp.toml file
+//	[templates]
+//	dir = "templates"
+//
+//	# Programs whose code for each class is appended to the generated file, which run in the directory of the
+//	# gopp.toml file. The arguments are separated by spaces, and cannot be quoted.
+//	[generators]
+//	validate = "go run ./tools/validate"
+//
+//	# Programs whose code for each class is written beside the generated file, in a file whose name ends with an
+//	# underscore and the key, like person_db.go. The key cannot end with test or a GOOS or GOARCH.
+//	[file_generators]
+//	db = "gopp-db -dialect postgres"
+type Config struct {
+	Naming     Naming
+	Templates  map[string]string // the sources of templates that replace the templates of the same names
+	Generators []GeneratorConfig // the programs that generate more code for each class, which are run as ExecGenerators
+
+	dir string // the directory of the configuration file, which generators run in
+}
+
+// GeneratorConfig is a program that generates more code for each class, which is given in a configuration file.
+type GeneratorConfig struct {
+	Name    string // the name of the generator, which is the suffix of the file it writes to if File is true
+	Command string // the program and its arguments, separated by spaces
+	File    bool   // true if the code is written beside the generated file, instead of being appended to it
+}
+
+// LoadConfig finds the gopp.toml file that applies to the .gpp files in dir, which is in dir or the closest of its
+// parent directories, and returns its configuration and path. If there is no gopp.toml file, it returns the default
+// configuration and an empty path. The returned error is a Diagnostics if the file has errors.
+func LoadConfig(dir string) (Config, string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return Config{}, "", err
+	}
+	for {
+		path := filepath.Join(abs, ConfigName)
+		src, err := ioutil.ReadFile(path)
+		if err == nil {
+			config, err := ParseConfig(path, src)
+			return config, path, err
+		}
+		if !os.IsNotExist(err) {
+			return Config{}, "", err
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return Config{}, "", nil
+		}
+		abs = parent
+	}
+}
+
+// ParseConfig parses the source of a gopp.toml file, and reads the templates in the directory it names. The returned
+// error is a Diagnostics if the file or the templates have errors.
+func ParseConfig(filename string, src []byte) (Config, error) {
+	var config Config
+	var diags Diagnostics
+	var templateDir string
+	table := ""
+	for i, line := range strings.Split(string(src), "\n") {
+		errorf := func(format string, args ...interface{}) {
+			diags = append(diags, diagnostic(filename, posErrorf(Position{Line: i + 1}, format, args...)))
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				errorf("missing ] after table name")
+				continue
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			switch table {
+			case "naming", "templates", "generators", "file_generators":
+			default:
+				errorf("unknown table %s", table)
+			}
+			continue
+		}
+
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			errorf("expected key = value")
+			continue
+		}
+		key := strings.TrimSpace(line[:eq])
+		value, err := configString(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			errorf("value of %s: %v", key, err)
+			continue
+		}
+
+		var field *string
+		switch table {
+		case "naming":
+			switch key {
+			case "interface":
+				field = &config.Naming.Interface
+			case "struct":
+				field = &config.Naming.Struct
+			case "constructor":
+				field = &config.Naming.Constructor
+			case "receiver":
+				field = &config.Naming.Receiver
+			}
+		case "templates":
+			if key == "dir" {
+				field = &templateDir
+			}
+		case "generators", "file_generators":
+			file := table == "file_generators"
+			if err := checkGenerator(config.Generators, key, value, file); err != nil {
+				errorf("%v", err)
+				continue
+			}
+			config.Generators = append(config.Generators, GeneratorConfig{Name: key, Command: value, File: file})
+			continue
+		}
+		if field == nil {
+			errorf("unknown key %s", key)
+			continue
+		}
+		*field = value
+	}
+
+	if diags == nil {
+		if err := config.Naming.Validate(); err != nil {
+			diags = append(diags, Diagnostic{File: filename, Message: "naming: " + err.Error()})
+		}
+	}
+	if diags != nil {
+		return Config{}, diags
+	}
+
+	config.dir = filepath.Dir(filename)
+	if templateDir != "" {
+		if !filepath.IsAbs(templateDir) {
+			templateDir = filepath.Join(filepath.Dir(filename), templateDir)
+		}
+		templates, err := LoadTemplates(templateDir)
+		if err != nil {
+			return Config{}, err
+		}
+		config.Templates = templates
+	}
+	return config, nil
+}
+
+// checkGenerator returns an error if a generator in a configuration file has no command, or has the name of another
+// generator of the same kind. The name of a file generator must be a suffix that the go tool does not treat specially,
+// so that a file like person_test.go or person_linux.go is not made a test or built for one platform only.
+func checkGenerator(gens []GeneratorConfig, name string, command string, file bool) error {
+	if strings.TrimSpace(command) == "" {
+		return errors.New("generator " + name + " has no command")
+	}
+	if file && !token.IsIdentifier(name) {
+		return errors.New("file generator " + name + " is not a valid file suffix")
+	}
+	if last := name[strings.LastIndex(name, "_")+1:]; file && (last == "test" || knownOS[last] || knownArch[last]) {
+		return errors.New("file generator " + name + " is a suffix that limits when the file is built")
+	}
+	for _, g := range gens {
+		if g.Name == name && g.File == file {
+			return errors.New("duplicate generator " + name)
+		}
+	}
+	return nil
+}
+
+// knownOS and knownArch are the values of GOOS and GOARCH that the go tool recognizes in file names, as listed by
+// go/build.
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true, "illumos": true,
+	"ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true, "openbsd": true, "plan9": true,
+	"solaris": true, "wasip1": true, "windows": true, "zos": true,
+}
+
+var knownArch = map[string]bool{
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true, "arm64be": true,
+	"loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true,
+	"mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true, "riscv": true, "riscv64": true, "s390": true,
+	"s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+}
+
+// configString returns the string of a quoted value in a configuration file, which can be followed by a comment.
+func configString(value string) (string, error) {
+	if strings.HasPrefix(value, "'") {
+		// A literal string, which has no escapes
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", errors.New("missing closing quote")
+		}
+		return value[1 : end+1], checkRest(value[end+2:])
+	}
+	if !strings.HasPrefix(value, `"`) {
+		return "", errors.New("expected a quoted string")
+	}
+	for end := 1; end < len(value); end++ {
+		switch value[end] {
+		case '\\':
+			end++
+		case '"':
+			s, err := strconv.Unquote(value[:end+1])
+			if err != nil {
+				return "", err
+			}
+			return s, checkRest(value[end+1:])
+		}
+	}
+	return "", errors.New("missing closing quote")
+}
+
+// checkRest returns an error if there is anything but a comment after a value.
+func checkRest(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return errors.New("unexpected text after the value: " + rest)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package translate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	src := `# Style guide naming
+[naming]
+interface = "{Name}"   # the interface gets the name of the class
+struct = '{name}'
+receiver = "{n}"
+`
+	config, err := ParseConfig("gopp.toml", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Naming{Interface: "{Name}", Struct: "{name}", Receiver: "{n}"}
+	if config.Naming != want {
+		t.Errorf("Wrong naming: %+v", config.Naming)
+	}
+
+	src = "[generators]\nvalidate = \"go run ./validate\"\n[file_generators]\ndb = 'gopp-db -dialect postgres'\n"
+	config, err = ParseConfig("gopp.toml", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantGens := []GeneratorConfig{
+		{Name: "validate", Command: "go run ./validate"},
+		{Name: "db", Command: "gopp-db -dialect postgres", File: true},
+	}
+	if !reflect.DeepEqual(config.Generators, wantGens) {
+		t.Errorf("Wrong generators: %+v", config.Generators)
+	}
+
+	for _, bad := range []string{
+		"[naming]\ninterface = {Name}\n",
+		"[naming]\nstructs = \"{name}\"\n",
+		"[names]\n",
+		"[naming]\ninterface = \"{Name}\" x\n",
+		"[naming]\ninterface = \"{Class}\"\n",
+		"[naming]\ninterface = \"{name}\"\nstruct = \"{name}\"\n",
+		"[naming]\nreceiver = \"this\"\n",
+		"[naming]\nreceiver = \"gopp\"\n",
+		"[generators]\nvalidate = \"\"\n",
+		"[file_generators]\ndb = \"a\"\ndb = \"b\"\n",
+		"[file_generators]\nmy-db = \"a\"\n",
+		"[file_generators]\ntest = \"a\"\n",
+		"[file_generators]\ndb_test = \"a\"\n",
+		"[file_generators]\nlinux = \"a\"\n",
+		"[file_generators]\ndb_linux_amd64 = \"a\"\n",
+	} {
+		if _, err := ParseConfig("gopp.toml", []byte(bad)); err == nil {
+			t.Errorf("Error not reported for %q", bad)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	config, path, err := LoadConfig(dir)
+	if err != nil || path != "" || config.Naming != (Naming{}) {
+		t.Errorf("Configuration found where there is none: %s %v", path, err)
+	}
+
+	file := filepath.Join(root, "a", ConfigName)
+	if err := ioutil.WriteFile(file, []byte("[naming]\nreceiver = \"{n}\"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	config, path, err = LoadConfig(dir)
+	if err != nil || path != file || config.Naming.Receiver != "{n}" {
+		t.Errorf("Configuration of the parent directory not found: %s %+v %v", path, config, err)
+	}
+}
@@ -0,0 +1,218 @@
+package translate
+
+import (
+	"fmt"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// inheritEqual makes each class of a file that extends an equatable class equatable too, so that its Equal and Hash
+// functions cover its own members, and finds the Equal function that it inherits from a superclass in another package,
+// so that the class can chain to it and take the same interface. The superclass is looked up in the packages of the
+// session first, and otherwise in the Go code of its package, which must have been generated already.
+func (s *Session) inheritEqual(tree ast) error {
+	for _, c := range tree.classes() {
+		c.inheritedEqual = ""
+		c.Equatable = c.decl.HasModifier(modEquatable)
+		top := c
+		// A cycle of superclasses is reported when the classes are resolved
+		visited := map[*classDef]bool{c: true}
+		for p := c.parentClass(); p != nil && !visited[p]; p = p.parentClass() {
+			visited[p] = true
+			if p.decl.HasModifier(modEquatable) {
+				c.Equatable = true
+			}
+			top = p
+		}
+		if !strings.Contains(top.Extends, ".") || strings.HasPrefix(top.Extends, "gopp.") {
+			continue
+		}
+		path, name, err := s.externalEqual(top)
+		if err != nil && c.Equatable {
+			return posErrorf(c.decl.Position, "superclass %s: %v", top.Extends, err)
+		}
+		// A class that is not equatable itself only becomes equatable if its superclass is known to be
+		if err != nil || name == "" {
+			continue
+		}
+		c.Equatable = true
+		qual := s.importName(c.file, path)
+		if qual == "" {
+			return posErrorf(c.decl.Position, "the inherited Equal function takes a %s, so %q must be imported", name, path)
+		}
+		c.inheritedEqual = qual + "." + name
+	}
+	return nil
+}
+
+// externalEqual returns the import path and name of the interface that the Equal function of the superclass of c
+// takes, where the superclass is in another package. It returns an empty name if the superclass is not equatable.
+func (s *Session) externalEqual(c *classDef) (path string, name string, err error) {
+	i := strings.LastIndex(c.Extends, ".")
+	qual, parent := c.Extends[:i], c.Extends[i+1:]
+	path = s.fileImports(c.file)[qual]
+	if path == "" {
+		return "", "", fmt.Errorf("package %s is not imported", qual)
+	}
+	dir := filepath.Dir(c.file)
+	pkgDir, err := packageDir(path, dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The superclass is in a package of the session
+	for _, scope := range s.scopes {
+		if scope.classes[parent] == nil || !sameDir(scope.dir, pkgDir) {
+			continue
+		}
+		top := scope.classes[parent]
+		var eq *classDef
+		visited := make(map[*classDef]bool)
+		for q := top; q != nil && !visited[q]; q = q.parentClass() {
+			visited[q] = true
+			if q.decl.HasModifier(modEquatable) {
+				eq = q
+			}
+			top = q
+		}
+		if strings.Contains(top.Extends, ".") && !strings.HasPrefix(top.Extends, "gopp.") {
+			if path, name, err := s.externalEqual(top); err != nil || name != "" {
+				return path, name, err
+			}
+		}
+		if eq == nil {
+			return "", "", nil
+		}
+		names, err := scope.config.Naming.names(eq.Name)
+		if err != nil {
+			return "", "", err
+		}
+		return path, names.Interface, nil
+	}
+
+	// The superclass is in Go code, whose struct is assumed to be named with the naming of the class
+	names, err := c.scope.config.Naming.names(parent)
+	if err != nil {
+		return "", "", err
+	}
+	pkg, err := s.importPackage(path, dir)
+	if err != nil {
+		return "", "", err
+	}
+	obj, ok := pkg.Scope().Lookup(names.Struct).(*types.TypeName)
+	if !ok {
+		return "", "", fmt.Errorf("%s.%s not found", path, names.Struct)
+	}
+	sel := types.NewMethodSet(types.NewPointer(obj.Type())).Lookup(nil, "Equal")
+	if sel == nil {
+		return "", "", nil
+	}
+	sig := sel.Obj().Type().(*types.Signature)
+	if sig.Params().Len() != 1 {
+		return "", "", fmt.Errorf("%s has an Equal function that does not take one value", c.Extends)
+	}
+	named, ok := sig.Params().At(0).Type().(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return "", "", fmt.Errorf("the Equal function of %s does not take the interface of a class", c.Extends)
+	}
+	return named.Obj().Pkg().Path(), named.Obj().Name(), nil
+}
+
+// importPackage loads the package with the given import path, as imported from dir, from its Go code. Packages are
+// only loaded once by a session.
+func (s *Session) importPackage(path string, dir string) (*types.Package, error) {
+	s.importMu.Lock()
+	defer s.importMu.Unlock()
+	if s.importer == nil {
+		s.importer = importer.ForCompiler(token.NewFileSet(), "source", nil).(types.ImporterFrom)
+	}
+	return s.importer.ImportFrom(path, dir, 0)
+}
+
+// packageDir returns the directory of the package with the given import path, as imported from dir. Packages of the
+// module of dir are found even if they do not have any Go files yet, since their code may not have been generated.
+func packageDir(path string, dir string) (string, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if data, err := ioutil.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			if m := moduleLine.FindSubmatch(data); m != nil {
+				mod := strings.Trim(string(m[1]), `"`)
+				if path == mod || strings.HasPrefix(path, mod+"/") {
+					return filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(path, mod))), nil
+				}
+			}
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			break
+		}
+		root = parent
+	}
+	p, err := build.Import(path, dir, build.FindOnly)
+	if err != nil {
+		return "", err
+	}
+	return p.Dir, nil
+}
+
+// moduleLine matches the module line of a go.mod file.
+var moduleLine = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// fileImports returns the import paths of the packages that a .gpp file imports, by the name they are imported with.
+// A package that is imported without a name is assumed to have the last element of its path as its name.
+func (s *Session) fileImports(filename string) map[string]string {
+	var src strings.Builder
+	for _, d := range s.files[filename] {
+		if t, ok := d.(goText); ok {
+			src.WriteString(t.text)
+		}
+	}
+	imports := make(map[string]string)
+	// The imports before a syntax error are still returned
+	f, _ := parser.ParseFile(token.NewFileSet(), filename, src.String(), parser.ImportsOnly)
+	if f == nil {
+		return imports
+	}
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+// importName returns the name that a .gpp file imports the package with the given import path with, or an empty
+// string if it does not import the package.
+func (s *Session) importName(filename string, path string) string {
+	for name, p := range s.fileImports(filename) {
+		if p == path {
+			return name
+		}
+	}
+	return ""
+}
+
+// sameDir returns true if the two paths name the same directory.
+func sameDir(a, b string) bool {
+	a, err1 := filepath.Abs(a)
+	b, err2 := filepath.Abs(b)
+	return err1 == nil && err2 == nil && a == b
+}
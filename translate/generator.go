@@ -0,0 +1,262 @@
+package translate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// A Generator generates extra code for each class, like database mappings or validation functions, along with the
+// code that gopp generates. GenerateClass is given the description of a class after all of the files of its package
+// were added, and returns Go declarations. The declarations can start with import declarations, which are added to the
+// imports of the file. GenerateClass can be called for several classes at the same time.
+//
+// If a Generator implements fmt.Stringer, its String method identifies it in the Hash of generated files, so it should
+// change when the code that it generates changes. An ExecGenerator is identified by its command, since the program
+// itself is not known, so see RunsPrograms.
+type Generator interface {
+	GenerateClass(c *ClassInfo) ([]byte, error)
+}
+
+// generatorEntry is a Generator used by a session, with the suffix of the file it writes its code to.
+type generatorEntry struct {
+	gen    Generator
+	suffix string // the suffix of the file, or empty if the code is appended to the generated file
+}
+
+// AddGenerator adds a Generator to the session. The code it generates for the classes of a file is appended to the
+// code generated by gopp, unless suffix is given, in which case it is returned in the Files of the Result, to be written
+// to a file beside the generated file, whose name ends with an underscore and the suffix, like person_db.go. Generators
+// run in the order they are added, followed by the generators of the configuration of the package.
+func (s *Session) AddGenerator(g Generator, suffix string) {
+	s.generators = append(s.generators, generatorEntry{g, suffix})
+}
+
+// ExecGenerator is a Generator that runs an external program for each class. The program is given the ClassInfo of
+// the class as JSON on its standard input, and writes the generated code to its standard output. The program fails if
+// it exits with a status other than 0, and what it writes to its standard error is reported.
+type ExecGenerator struct {
+	Command []string // the program and its arguments
+	Dir     string   // the directory to run the program in, or empty for the current directory
+}
+
+// GenerateClass runs the program with the description of the class.
+func (g *ExecGenerator) GenerateClass(c *ClassInfo) ([]byte, error) {
+	if len(g.Command) == 0 {
+		return nil, fmt.Errorf("no command given")
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(g.Command[0], g.Command[1:]...)
+	cmd.Dir = g.Dir
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %v: %s", g.Command[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s: %v", g.Command[0], err)
+	}
+	return out, nil
+}
+
+// String returns the command line of the program.
+func (g *ExecGenerator) String() string {
+	return strings.Join(g.Command, " ")
+}
+
+// generatorName returns the name of a generator that identifies it in the hash of generated files.
+func generatorName(g Generator) string {
+	if s, ok := g.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", g)
+}
+
+// fileGenerators returns the generators of a file, which are the generators of the session followed by the
+// generators of the configuration of its package. A file without classes has no generators.
+func (s *Session) fileGenerators(tree ast) []generatorEntry {
+	classes := tree.classes()
+	if len(classes) == 0 {
+		return nil
+	}
+	gens := append([]generatorEntry{}, s.generators...)
+	config := classes[0].scope.config
+	for _, g := range config.Generators {
+		entry := generatorEntry{gen: &ExecGenerator{Command: strings.Fields(g.Command), Dir: config.dir}}
+		if g.File {
+			entry.suffix = g.Name
+		}
+		gens = append(gens, entry)
+	}
+	return gens
+}
+
+// RunsPrograms reports whether generating the named file runs external programs, with an ExecGenerator of the
+// session or of the configuration of its package. Only the commands of the programs are part of the Hash, so a file
+// whose hash matches may still be out of date if a program changed.
+func (s *Session) RunsPrograms(filename string) bool {
+	for _, entry := range s.fileGenerators(s.files[filename]) {
+		if _, ok := entry.gen.(*ExecGenerator); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FileSuffixes returns the suffixes of the files that generators write beside the code generated for the named file.
+// The Files of the Result of the file hold a file for each of them.
+func (s *Session) FileSuffixes(filename string) []string {
+	var suffixes []string
+	seen := make(map[string]bool)
+	for _, entry := range s.fileGenerators(s.files[filename]) {
+		if entry.suffix != "" && !seen[entry.suffix] {
+			seen[entry.suffix] = true
+			suffixes = append(suffixes, entry.suffix)
+		}
+	}
+	return suffixes
+}
+
+// generatorCode is the code that generators made for a file, with the imports it needs.
+type generatorCode struct {
+	imports []string // the import specs, like "fmt" or name "path"
+	code    bytes.Buffer
+}
+
+// runGenerators runs the generators of a file for each of its classes, and returns the code they generate by the suffix
+// of the file the code goes to, where the empty suffix is the generated file itself. Problems are added to r.
+func (s *Session) runGenerators(filename string, tree ast, r *Result) map[string]*generatorCode {
+	gens := s.fileGenerators(tree)
+	if len(gens) == 0 {
+		return nil
+	}
+	codes := make(map[string]*generatorCode)
+	for _, entry := range gens {
+		if codes[entry.suffix] == nil {
+			codes[entry.suffix] = &generatorCode{}
+		}
+		for i, c := range tree.classes() {
+			out, err := entry.gen.GenerateClass(&r.Classes[i])
+			if err == nil {
+				out, err = addGeneratorCode(codes, entry.suffix, out)
+			}
+			if err != nil {
+				r.Diagnostics = append(r.Diagnostics, diagnostic(filename, posErrorf(c.decl.Position,
+					"generator %s: class %s: %v", generatorName(entry.gen), c.Name, err)))
+			}
+		}
+	}
+	return codes
+}
+
+// addGeneratorCode adds the code that a generator made for a class to the code of the file with the given suffix.
+func addGeneratorCode(codes map[string]*generatorCode, suffix string, out []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(out)) == 0 {
+		return out, nil
+	}
+	imports, rest, err := splitImports(out)
+	if err != nil {
+		return nil, err
+	}
+	code := codes[suffix]
+	code.imports = append(code.imports, imports...)
+	code.code.WriteString("\n")
+	code.code.Write(rest)
+	code.code.WriteString("\n")
+	return out, nil
+}
+
+// splitImports splits Go declarations into the import specs of the import declarations at their start, and the rest
+// of the declarations.
+func splitImports(code []byte) ([]string, []byte, error) {
+	const pkg = "package p\n"
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", pkg+string(code), parser.ImportsOnly)
+	if err != nil {
+		return nil, nil, err
+	}
+	var imports []string
+	for _, spec := range f.Imports {
+		s := spec.Path.Value
+		if spec.Name != nil {
+			s = spec.Name.Name + " " + s
+		}
+		imports = append(imports, s)
+	}
+	if len(f.Decls) == 0 {
+		return nil, code, nil
+	}
+	end := fset.Position(f.Decls[len(f.Decls)-1].End()).Offset - len(pkg)
+	return imports, code[end:], nil
+}
+
+// addImports adds import declarations for the import specs that formatted Go code does not already import, after the
+// package clause.
+func addImports(src []byte, imports []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[string]bool)
+	for _, spec := range f.Imports {
+		have[spec.Path.Value] = true
+	}
+	var decls bytes.Buffer
+	for _, spec := range imports {
+		path := spec[strings.LastIndex(spec, " ")+1:]
+		if p, err := strconv.Unquote(path); err != nil || have[path] || p == "" {
+			continue
+		}
+		have[path] = true
+		decls.WriteString("import " + spec + "\n")
+	}
+	if decls.Len() == 0 {
+		return src, nil
+	}
+	i := fset.Position(f.Name.End()).Offset
+	out := append([]byte{}, src[:i]...)
+	out = append(out, "\n\n"...)
+	out = append(out, decls.Bytes()...)
+	return append(out, src[i:]...), nil
+}
+
+// generatorFile returns the formatted code of the file with the given suffix, which holds code made by generators.
+func generatorFile(pkg string, code *generatorCode) ([]byte, error) {
+	src := []byte(generatedHeader + "package " + pkg + "\n")
+	src, err := addImports(src, code.imports)
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(append(src, code.code.Bytes()...))
+}
+
+// appendCode appends code made by generators to the formatted code generated for a file, and formats it again.
+func appendCode(src []byte, code *generatorCode) ([]byte, error) {
+	src, err := addImports(src, code.imports)
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(append(src, code.code.Bytes()...))
+}
+
+// SuffixName returns the name of the file that holds the code that generators write beside a generated .go file, which
+// is the name of the .go file with an underscore and the suffix before the extension, like person_db.go. If suffix is
+// empty, it returns the name of the .go file.
+func SuffixName(goFile string, suffix string) string {
+	if suffix == "" {
+		return goFile
+	}
+	return strings.TrimSuffix(goFile, ".go") + "_" + suffix + ".go"
+}
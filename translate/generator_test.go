@@ -0,0 +1,83 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// testGenerator generates a function that uses the fmt package for each class.
+type testGenerator struct{}
+
+func (testGenerator) GenerateClass(c *ClassInfo) ([]byte, error) {
+	if c.Name == "Bad" {
+		return nil, errors.New("bad class")
+	}
+	return []byte(fmt.Sprintf("import \"fmt\"\n\nfunc (%s *%s) Describe() string {\n\treturn fmt.Sprint(%q, %q)\n}\n",
+		c.Receiver, c.Struct, c.Name, strings.Join(c.Parents, ","))), nil
+}
+
+func TestGenerator(t *testing.T) {
+	s := NewSession(Options{})
+	s.AddGenerator(testGenerator{}, "")
+	s.AddGenerator(testGenerator{}, "describe")
+	s.Add("animal.gpp", []byte("package zoo\n\nclass Animal extends gopp.Base {\n}\n\nclass Dog extends Animal {\n}\n"))
+	r, err := s.Generate("animal.gpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"import \"fmt\"\n",
+		"func (a_ *Animal) Describe() string {\n\treturn fmt.Sprint(\"Animal\", \"\")\n}",
+		"func (d_ *Dog) Describe() string {\n\treturn fmt.Sprint(\"Dog\", \"Animal\")\n}",
+	} {
+		if !strings.Contains(string(r.Source), want) {
+			t.Errorf("Missing %q in:\n%s", want, r.Source)
+		}
+		if !strings.Contains(string(r.Files["describe"]), want) {
+			t.Errorf("Missing %q in:\n%s", want, r.Files["describe"])
+		}
+	}
+	file := string(r.Files["describe"])
+	if !IsGenerated(r.Files["describe"]) || GeneratedHash(r.Files["describe"]) != s.Hash("animal.gpp") ||
+		!strings.Contains(file, "package zoo\n") {
+		t.Errorf("Wrong generated file:\n%s", file)
+	}
+	if suffixes := s.FileSuffixes("animal.gpp"); len(suffixes) != 1 || suffixes[0] != "describe" {
+		t.Errorf("Wrong suffixes: %v", suffixes)
+	}
+	if s.RunsPrograms("animal.gpp") {
+		t.Error("Generators in Go code run programs")
+	}
+	s.AddGenerator(&ExecGenerator{Command: []string{"true"}}, "")
+	if !s.RunsPrograms("animal.gpp") {
+		t.Error("ExecGenerator does not run a program")
+	}
+
+	s.Add("bad.gpp", []byte("package zoo\n\nclass Bad extends gopp.Base {\n}\n"))
+	r, err = s.Generate("bad.gpp")
+	if err == nil || r.Source != nil || r.Files != nil {
+		t.Fatal("Generator error not reported")
+	}
+	if d := r.Diagnostics[0]; d.Line != 3 || !strings.Contains(d.Message, "bad class") {
+		t.Errorf("Wrong diagnostic: %v", d)
+	}
+}
+
+func TestExecGenerator(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell")
+	}
+	g := &ExecGenerator{Command: []string{"sh", "-c", `grep -q '"Struct":"Animal"' && echo "const AnimalKind = 1"`}}
+	out, err := g.GenerateClass(&ClassInfo{Name: "Animal", Struct: "Animal"})
+	if err != nil || string(out) != "const AnimalKind = 1\n" {
+		t.Errorf("Wrong output %q: %v", out, err)
+	}
+
+	g = &ExecGenerator{Command: []string{"sh", "-c", "echo failed >&2; exit 1"}}
+	if _, err := g.GenerateClass(&ClassInfo{}); err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("Wrong error: %v", err)
+	}
+}
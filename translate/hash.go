@@ -0,0 +1,92 @@
+package translate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hashPrefix starts the line of the header of a generated file that records the hash of its inputs.
+const hashPrefix = "//gopp:hash "
+
+// Hash returns a hash of everything that the code generated from the named file depends on: the source of the file,
+// the sources of the files in the session that declare the superclasses of its classes, the options of the session,
+// the configuration of its package, with the naming and templates, and the version of gopp. Generate records it in the
+// header of the generated code, so that a file does not need to be generated again if the hash in its header matches.
+// Superclasses in other packages are not included.
+func (s *Session) Hash(filename string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "gopp %s\n%+v\n", Version, s.opts)
+
+	files := []string{filename}
+	seen := map[string]bool{filename: true}
+	var parents []string
+	if tree, ok := s.files[filename]; ok {
+		if classes := tree.classes(); len(classes) > 0 {
+			// The configuration of the package, which can be set for its directory instead of in the options
+			config := classes[0].scope.config
+			fmt.Fprintf(h, "%+v\n%+v\n%+v\n", config.Naming, config.Templates, config.Generators)
+		}
+		for _, g := range s.generators {
+			fmt.Fprintf(h, "generator %s %s\n", generatorName(g.gen), g.suffix)
+		}
+		for _, c := range tree.classes() {
+			visited := make(map[*classDef]bool)
+			for p := c.parentClass(); p != nil && !visited[p]; p = p.parentClass() {
+				visited[p] = true
+				if !seen[p.file] {
+					seen[p.file] = true
+					parents = append(parents, p.file)
+				}
+			}
+		}
+	}
+	sort.Strings(parents)
+	files = append(files, parents...)
+
+	for _, file := range files {
+		src := s.srcs[file]
+		// Only the base name is used, since all of the files are in the same directory, and the path depends on where
+		// gopp is run from
+		fmt.Fprintf(h, "%s %d\n", filepath.Base(file), len(src))
+		h.Write(src)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GeneratedHash returns the hash of the inputs recorded in the header of code generated by gopp, or an empty string if
+// there is none.
+func GeneratedHash(src []byte) string {
+	for len(src) > 0 && bytes.HasPrefix(src, []byte("//")) {
+		line := src
+		if i := bytes.IndexByte(src, '\n'); i >= 0 {
+			line, src = src[:i], src[i+1:]
+		} else {
+			src = nil
+		}
+		if bytes.HasPrefix(line, []byte(hashPrefix)) {
+			return string(bytes.TrimSpace(line[len(hashPrefix):]))
+		}
+	}
+	return ""
+}
+
+// addHash adds the line that records the hash of the inputs after the first line of generated code.
+func addHash(src []byte, hash string) []byte {
+	i := bytes.IndexByte(src, '\n') + 1
+	out := make([]byte, 0, len(src)+len(hashPrefix)+len(hash)+1)
+	out = append(out, src[:i]...)
+	out = append(out, hashPrefix+hash+"\n"...)
+	return append(out, src[i:]...)
+}
+
+// IsGenerated reports whether src is code generated by gopp, which starts with the header that gopp adds. The header
+// of earlier versions of gopp is recognized too.
+func IsGenerated(src []byte) bool {
+	return bytes.HasPrefix(src, []byte(strings.TrimSpace(generatedHeader))) ||
+		bytes.HasPrefix(src, []byte(oldGeneratedHeader))
+}
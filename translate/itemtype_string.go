@@ -1,12 +1,12 @@
 // Code generated by "stringer -type=itemType"; DO NOT EDIT
 
-package main
+package translate
 
 import "fmt"
 
-const _itemType_name = "itemErroritemDotitemEOFitemClassitemExtendsitemOpenBraceitemCloseBraceitemFuncitemTextitemLeftDelimitemRightDelimitemFuncBodyitemFuncParamsitemMemberitemCommentitemLineComment"
+const _itemType_name = "itemErroritemDotitemEOFitemClassitemExtendsitemOpenBraceitemCloseBraceitemFuncitemOverrideitemTextitemLeftDelimitemRightDelimitemFuncBodyitemFuncParamsitemMemberitemCommentitemLineCommentitemPackageitemClassModifier"
 
-var _itemType_index = [...]uint8{0, 9, 16, 23, 32, 43, 56, 70, 78, 86, 99, 113, 125, 139, 149, 160, 175}
+var _itemType_index = [...]uint8{0, 9, 16, 23, 32, 43, 56, 70, 78, 90, 98, 111, 125, 137, 151, 161, 172, 187, 198, 215}
 
 func (i itemType) String() string {
 	if i < 0 || i >= itemType(len(_itemType_index)-1) {
@@ -1,7 +1,8 @@
-package main
+package translate
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -16,6 +17,7 @@ const (
 	lineComment  = "//"
 )
 
+// Pos is the byte offset of an item in the input.
 type Pos int
 
 // reserved words and other tokens we care about
@@ -32,6 +34,7 @@ type itemType int
 
 type item struct {
 	typ itemType
+	pos Pos // the offset of the item in the input
 	val string
 }
 
@@ -54,6 +57,7 @@ const (
 	itemComment
 	itemLineComment
 	itemPackage
+	itemClassModifier
 )
 
 func (i item) String() string {
@@ -76,12 +80,13 @@ func (i item) String() string {
 }
 
 type lexer struct {
-	input   string    // string being scanned
-	start   int       // start position of item
-	pos     int       // current position
-	lastPos int       // last position of item read
-	width   int       // width of last rune
-	items   chan item // channel of scanned items
+	name  string    // the name of the file being scanned, used in positions
+	input string    // string being scanned
+	lines []int     // the offsets of the starts of the lines of the input
+	start int       // start position of item
+	pos   int       // current position
+	width int       // width of last rune
+	items chan item // channel of scanned items
 }
 
 type stateFn func(*lexer) stateFn
@@ -102,22 +107,45 @@ func (l *lexer) nextItem() item {
 		panic("Read past end of file")
 	}
 
-	l.lastPos = l.pos
-
 	return item
 }
 
-func lex(input string) *lexer {
+// drain reads the rest of the items so that the lexing goroutine can finish. It is used when the parser stops early.
+func (l *lexer) drain() {
+	for range l.items {
+	}
+}
+
+func lex(name string, input string) *lexer {
 	l := &lexer{
+		name:  name,
 		input: input,
+		lines: []int{0},
 		items: make(chan item),
 	}
+	for i := 0; i < len(input); i++ {
+		if input[i] == '\n' {
+			l.lines = append(l.lines, i+1)
+		}
+	}
 	go l.run()
 	return l
 }
 
+// position returns the line and column of the given offset in the input.
+func (l *lexer) position(pos Pos) Position {
+	offset := int(pos)
+	line := sort.Search(len(l.lines), func(i int) bool { return l.lines[i] > offset })
+	return Position{
+		Filename: l.name,
+		Offset:   offset,
+		Line:     line,
+		Column:   offset - l.lines[line-1] + 1,
+	}
+}
+
 func (l *lexer) emit(t itemType) {
-	item := item{t, l.input[l.start:l.pos]}
+	item := item{t, Pos(l.start), l.input[l.start:l.pos]}
 	l.items <- item
 	l.start = l.pos
 	//fmt.Printf("%v", item)
@@ -137,7 +165,10 @@ func lexText(l *lexer) stateFn {
 				l.emit(itemText) // emit text already read so far for straight output
 			}
 			return lexComment(l, lexText)
-		} else if strings.HasPrefix(l.input[l.pos:], tokPackage) {
+		} else if strings.HasPrefix(l.input[l.pos:], tokPackage+" ") {
+			if l.pos > l.start {
+				l.emit(itemText)
+			}
 			return lexIdentifier(l, itemPackage, lexText)
 		}
 
@@ -249,7 +280,21 @@ func lexExtends(l *lexer) stateFn {
 }
 
 func lexExtendsClassName(l *lexer) stateFn {
-	return lexIdentifier(l, itemExtends, lexBodyOpen)
+	return lexIdentifier(l, itemExtends, lexClassModifiers)
+}
+
+// lexClassModifiers lexes the optional words that modify a class, which come between the name of the superclass and
+// the opening bracket.
+func lexClassModifiers(l *lexer) stateFn {
+	l.ignoreSpace()
+	if strings.HasPrefix(l.input[l.pos:], leftDelim) {
+		return lexBodyOpen
+	}
+	if l.acceptIdentifier() == "" {
+		return l.errorf("Expected opening bracket for class.")
+	}
+	l.emit(itemClassModifier)
+	return lexClassModifiers
 }
 
 func lexBodyOpen(l *lexer) stateFn {
@@ -273,6 +318,9 @@ func lexRightDelim(l *lexer) {
 
 func lexClassBody(l *lexer) stateFn {
 	l.ignoreSpace()
+	if l.peek() == eof {
+		return l.errorf("Unexpected EOF. Class body is still open.")
+	}
 	if strings.HasPrefix(l.input[l.pos:], rightDelim) {
 		return lexClassClose
 	}
@@ -392,9 +440,43 @@ func lexFuncBody(l *lexer) stateFn {
 	return lexClassBody
 }
 
+/**
+Lex a member declaration. A member normally ends at the end of its line, but the type can span several lines if it
+is an inline struct or func type, so brackets are matched. Strings, tags and comments are skipped so that brackets
+inside of them are not counted.
+*/
 func lexMember(l *lexer) stateFn {
-	l.nextLine()
-	if l.start+1 < l.pos {
+	var depth int
+loop:
+	for {
+		switch r := l.next(); r {
+		case eof:
+			break loop
+		case '\n':
+			if depth <= 0 {
+				break loop
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth < 0 {
+				// the closing bracket of the class is on the same line as the member
+				l.backup()
+				break loop
+			}
+		case '"', '`', '\'':
+			l.pos = skipQuoted(l.input, l.pos-1) + 1
+		case '/':
+			if p := l.peek(); p == '/' || p == '*' {
+				l.pos = skipComment(l.input, l.pos-1) + 1
+			}
+		}
+		if l.pos > len(l.input) {
+			l.pos = len(l.input)
+		}
+	}
+	if strings.TrimSpace(l.input[l.start:l.pos]) != "" {
 		l.emit(itemMember)
 	} else {
 		l.ignore()
@@ -426,7 +508,7 @@ func isIdChar(r rune) bool {
 // errorf returns an error token and terminates the scan by passing
 // back a nil pointer that will be the next state, terminating l.nextItem.
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.items <- item{itemError, fmt.Sprintf(format, args...)}
+	l.items <- item{itemError, Pos(l.start), fmt.Sprintf(format, args...)}
 	return nil
 }
 
@@ -454,7 +536,7 @@ func (l *lexer) acceptRun(valid string) {
 }
 
 func acceptUntil(l *lexer, terminators string) {
-	for strings.IndexRune(terminators, l.next()) < 0 {
+	for r := l.next(); r != eof && strings.IndexRune(terminators, r) < 0; r = l.next() {
 	}
 	l.backup()
 }
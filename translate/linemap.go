@@ -0,0 +1,109 @@
+package translate
+
+import (
+	"go/scanner"
+	"sort"
+	"strings"
+)
+
+// lineMapping maps a range of lines of generated code to the .gpp file. The range ends where the next mapping begins.
+type lineMapping struct {
+	gen    int  // the first line of the range in the generated code
+	src    int  // the line in the .gpp file that the first line comes from
+	linear bool // true if each following line of the range comes from the following line of the .gpp file
+}
+
+// lineMap maps the lines of generated code back to the lines of the .gpp file they come from, so that problems found
+// in the generated code can be reported where they can be fixed. The mappings are sorted by their generated line.
+type lineMap []lineMapping
+
+// srcLine returns the line in the .gpp file that the given line of generated code comes from, or 0 if it is not
+// known.
+func (m lineMap) srcLine(gen int) int {
+	i := sort.Search(len(m), func(i int) bool { return m[i].gen > gen }) - 1
+	if i < 0 {
+		return 0
+	}
+	if m[i].linear {
+		return m[i].src + gen - m[i].gen
+	}
+	return m[i].src
+}
+
+// generate returns the Go code of the ast, along with the map of its lines.
+func (a ast) generate() (string, lineMap) {
+	var out strings.Builder
+	var m lineMap
+	line := 1
+	for _, s := range a {
+		code := s.String()
+		switch v := s.(type) {
+		case goText:
+			m = append(m, lineMapping{line, v.line, true})
+		case *classDef:
+			m = append(m, v.lineMap(code, line)...)
+		}
+		out.WriteString(code)
+		line += strings.Count(code, "\n")
+	}
+	return out.String(), m
+}
+
+// lineMap returns the map of the lines of the generated code of the class, which starts at the line start. Member
+// declarations and methods are found in the code by their text and mapped to their own lines, and the rest of the
+// code is mapped to the line of the class declaration.
+func (c *classDef) lineMap(code string, start int) lineMap {
+	m := lineMap{{start, c.decl.Position.Line, false}}
+	lines := strings.Split(code, "\n")
+	members := c.Members
+	funcs := c.Funcs
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if len(members) > 0 && line == "\t"+firstLine(members[0].StructField()) {
+			m = append(m, lineMapping{start + i, members[0].Position.Line, true})
+			i += strings.Count(members[0].StructField(), "\n")
+			m = append(m, lineMapping{start + i + 1, c.decl.Position.Line, false})
+			members = members[1:]
+		} else if len(funcs) > 0 && strings.HasPrefix(line, "func ("+c.Receiver+" *"+c.Struct+") "+funcs[0].Name+" ") {
+			m = append(m, lineMapping{start + i, funcs[0].Line, true})
+			funcs = funcs[1:]
+		} else if line == "}" {
+			// the end of a method, or of a declaration that is already mapped to the class
+			m = append(m, lineMapping{start + i + 1, c.decl.Position.Line, false})
+		}
+	}
+	return m
+}
+
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// headerLines is the number of lines of the header that is added before the generated code.
+var headerLines = strings.Count(generatedHeader, "\n")
+
+// fileLine returns the line in the .gpp file that a line of a generated file, which includes the header, comes from.
+func (m lineMap) fileLine(line int) int {
+	return m.srcLine(line - headerLines)
+}
+
+// codeDiagnostics returns the Diagnostics of the syntax errors that were found in a generated file, with their lines
+// mapped back to the .gpp file.
+func codeDiagnostics(file string, err error, m lineMap) Diagnostics {
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return Diagnostics{{File: file, Message: "cannot format generated code: " + err.Error()}}
+	}
+	var diags Diagnostics
+	for _, e := range list {
+		diags = append(diags, Diagnostic{
+			File:    file,
+			Line:    m.fileLine(e.Pos.Line),
+			Message: "syntax error in generated code: " + e.Msg,
+		})
+	}
+	return diags
+}
@@ -0,0 +1,410 @@
+package translate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// memberDef is a member variable of a class, as used for code generation.
+type memberDef struct {
+	*MemberDecl
+	field string // the one variable of the member that the memberDef refers to, if it is split by classDef.Fields
+}
+
+// Annotation is a gopp annotation on a member, like @json("name") or @readonly. Annotations that have a value are
+// turned into struct tags, and annotations without a value are collected into the "gopp" struct tag. Code generators
+// can also act on them.
+type Annotation struct {
+	Name     string
+	Value    string
+	HasValue bool
+}
+
+// annotations that gopp itself acts on
+const (
+	annoReadOnly = "readonly" // the member is not decoded from JSON
+	annoRequired = "required" // decoding JSON fails if the member is missing
+	annoNoCopy   = "nocopy"   // the member is not copied, compared, hashed or encoded
+)
+
+// lockTypes are the types of the sync package that must not be copied. Members of these types, and of the types of the
+// sync/atomic package, are treated as if they were marked @nocopy.
+var lockTypes = map[string]bool{
+	"sync.Mutex": true, "sync.RWMutex": true, "sync.WaitGroup": true, "sync.Once": true, "sync.Cond": true,
+	"sync.Map": true, "sync.Pool": true,
+}
+
+// goppTagKey is the key of the struct tag that holds annotations without values.
+const goppTagKey = "gopp"
+
+// parseMember parses a member declaration in the body of a class, which is a Go struct field declaration optionally
+// followed by annotations and a default value:
+//
+//	name string `json:"n"` @db("name") @readonly = "none"
+//
+// All forms of field declarations are accepted, including several names (x, y int), types that span several lines
+// (an inline struct or func type) and embedded types.
+func parseMember(decl string, comment string) (m *MemberDecl, err error) {
+	m = &MemberDecl{Comment: comment}
+	decl = strings.TrimSpace(decl)
+
+	end := typeEnd(decl)
+	m.Names, m.Type, err = splitNames(decl[:end])
+	if err != nil {
+		return
+	}
+	if m.Type == "" {
+		return m, fmt.Errorf("missing member type in %q", decl)
+	}
+
+	rest := strings.TrimSpace(decl[end:])
+	var tags []string
+	if strings.HasPrefix(rest, "`") {
+		i := strings.Index(rest[1:], "`")
+		if i < 0 {
+			return m, fmt.Errorf("unclosed struct tag in %q", decl)
+		}
+		m.RawTag = rest[1 : i+1]
+		tags = append(tags, m.RawTag)
+		rest = strings.TrimSpace(rest[i+2:])
+	}
+
+	var flags []string
+	for strings.HasPrefix(rest, "@") {
+		var a Annotation
+		a, rest, err = parseAnnotation(rest)
+		if err != nil {
+			return
+		}
+		m.Annotations = append(m.Annotations, a)
+		if a.HasValue {
+			tags = append(tags, a.Name+":"+strconv.Quote(a.Value))
+		} else {
+			flags = append(flags, a.Name)
+		}
+	}
+	if len(flags) > 0 {
+		tags = append(tags, goppTagKey+":"+strconv.Quote(strings.Join(flags, ",")))
+	}
+	if strings.HasPrefix(rest, "=") {
+		rest = rest[1:]
+		end = valueEnd(rest)
+		m.Default = strings.TrimSpace(rest[:end])
+		rest = strings.TrimSpace(rest[end:])
+		if m.Default == "" {
+			return m, fmt.Errorf("missing default value after member %s", m.StructField())
+		}
+		if len(m.Names) == 0 {
+			return m, fmt.Errorf("embedded type %s cannot have a default value", m.Type)
+		}
+	}
+	if rest != "" && !strings.HasPrefix(rest, lineComment) && !strings.HasPrefix(rest, leftComment) {
+		return m, fmt.Errorf("unexpected %q after member %s", rest, m.Type)
+	}
+	m.LineComment = rest
+
+	m.Tag = strings.Join(tags, " ")
+	if err = validateTag(m.Tag); err != nil {
+		return m, fmt.Errorf("member %s: %s", m.StructField(), err)
+	}
+	return
+}
+
+// typeEnd returns the position in the member declaration decl where the type ends, which is at the struct tag, the
+// first Annotation, the default value or a comment. Brackets, quoted strings and comments that are part of the type
+// are skipped, since an inline struct type can have tags and comments of its own.
+func typeEnd(decl string) int {
+	depth := 0
+	for i := 0; i < len(decl); i++ {
+		switch c := decl[i]; c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '@', '=':
+			if depth == 0 {
+				return i
+			}
+		case '`', '"':
+			if depth == 0 && c == '`' {
+				return i
+			}
+			i = skipQuoted(decl, i)
+		case '/':
+			if strings.HasPrefix(decl[i:], lineComment) || strings.HasPrefix(decl[i:], leftComment) {
+				if depth == 0 {
+					return i
+				}
+				i = skipComment(decl, i)
+			}
+		}
+	}
+	return len(decl)
+}
+
+// valueEnd returns the position in s where the default value of a member ends, which is at the end of s or at a
+// comment that is not nested in the value.
+func valueEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '`', '"', '\'':
+			i = skipQuoted(s, i)
+		case '/':
+			if strings.HasPrefix(s[i:], lineComment) || strings.HasPrefix(s[i:], leftComment) {
+				if depth == 0 {
+					return i
+				}
+				i = skipComment(s, i)
+			}
+		}
+	}
+	return len(s)
+}
+
+// skipQuoted returns the position of the quote that ends the quoted string that starts at s[start].
+func skipQuoted(s string, start int) int {
+	q := s[start]
+	for i := start + 1; i < len(s); i++ {
+		if s[i] == '\\' && q != '`' {
+			i++
+		} else if s[i] == q {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// skipComment returns the position of the last character of the comment that starts at s[start].
+func skipComment(s string, start int) int {
+	if strings.HasPrefix(s[start:], lineComment) {
+		if i := strings.IndexByte(s[start:], '\n'); i >= 0 {
+			return start + i - 1
+		}
+	} else if i := strings.Index(s[start+2:], rightComment); i >= 0 {
+		return start + 2 + i + len(rightComment) - 1
+	}
+	return len(s)
+}
+
+// splitNames splits the names of the variables in a member declaration from the type. No names are returned for an
+// embedded type.
+func splitNames(decl string) (names []string, typ string, err error) {
+	decl = strings.TrimSpace(decl)
+	n := identLen(decl)
+	if n == 0 || n == len(decl) || decl[n] == '.' || decl[n] == '[' {
+		// An embedded type, like Foo, *Foo, pkg.Foo or Foo[int]
+		return nil, decl, nil
+	}
+	for {
+		names = append(names, decl[:n])
+		decl = strings.TrimSpace(decl[n:])
+		if !strings.HasPrefix(decl, ",") {
+			break
+		}
+		decl = strings.TrimSpace(decl[1:])
+		n = identLen(decl)
+		if n == 0 {
+			return nil, "", fmt.Errorf("missing member name after %s", names[len(names)-1])
+		}
+	}
+	return names, decl, nil
+}
+
+// identLen returns the length of the Go identifier at the start of s.
+func identLen(s string) int {
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// embeddedName returns the name of the field of an embedded type, which is the name of the type without a pointer,
+// package or type arguments, like Mutex for sync.Mutex.
+func embeddedName(typ string) string {
+	typ = strings.TrimSpace(strings.TrimPrefix(typ, "*"))
+	if i := strings.IndexByte(typ, '['); i >= 0 {
+		typ = typ[:i]
+	}
+	return typ[strings.LastIndex(typ, ".")+1:]
+}
+
+// parseAnnotation parses the Annotation at the start of s, which begins with @, and returns the rest of s.
+func parseAnnotation(s string) (a Annotation, rest string, err error) {
+	i := 1
+	for i < len(s) && isIdChar(rune(s[i])) && s[i] != '.' {
+		i++
+	}
+	a.Name = s[1:i]
+	if a.Name == "" {
+		return a, "", fmt.Errorf("missing Annotation name in %q", s)
+	}
+	rest = s[i:]
+	if strings.HasPrefix(rest, "(") {
+		// The value is a quoted string, which can hold a closing bracket
+		value := strings.TrimLeft(rest[1:], " \t")
+		if value == "" || (value[0] != '"' && value[0] != '`') {
+			return a, "", fmt.Errorf("the value of Annotation @%s must be a quoted string", a.Name)
+		}
+		q := skipQuoted(value, 0)
+		if q == len(value) {
+			return a, "", fmt.Errorf("unclosed string in Annotation @%s", a.Name)
+		}
+		if a.Value, err = strconv.Unquote(value[:q+1]); err != nil {
+			return a, "", fmt.Errorf("bad string in Annotation @%s: %v", a.Name, err)
+		}
+		rest = strings.TrimLeft(value[q+1:], " \t")
+		if !strings.HasPrefix(rest, ")") {
+			return a, "", fmt.Errorf("unclosed Annotation @%s", a.Name)
+		}
+		a.HasValue = true
+		rest = rest[1:]
+	}
+	return a, strings.TrimSpace(rest), nil
+}
+
+// validateTag returns an error if tag does not follow the conventional format of struct tags, as described in the
+// documentation of reflect.StructTag, or if it repeats a key.
+func validateTag(tag string) error {
+	keys := make(map[string]bool)
+	for tag != "" {
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return fmt.Errorf("bad syntax for struct tag %q", tag)
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan the quoted string to find its end
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return fmt.Errorf("bad syntax for struct tag value of %s", key)
+		}
+		if _, err := strconv.Unquote(tag[:i+1]); err != nil {
+			return fmt.Errorf("bad syntax for struct tag value of %s", key)
+		}
+		if keys[key] {
+			return fmt.Errorf("struct tag key %s is repeated", key)
+		}
+		keys[key] = true
+		tag = strings.TrimLeft(tag[i+1:], " ")
+	}
+	return nil
+}
+
+// Name returns the name of the member variable, or an empty string if the member is an embedded type. If the member
+// declares more than one variable, the first one is returned.
+func (m memberDef) Name() string {
+	if m.field != "" {
+		return m.field
+	}
+	if len(m.Names) == 0 {
+		return ""
+	}
+	return m.Names[0]
+}
+
+// StructField returns the declaration of the member as a Go struct field.
+func (m *MemberDecl) StructField() string {
+	out := m.Type
+	if len(m.Names) > 0 {
+		out = strings.Join(m.Names, ", ") + " " + out
+	}
+	if m.Tag != "" {
+		out += " `" + m.Tag + "`"
+	}
+	if m.LineComment != "" {
+		out += " " + m.LineComment
+	}
+	return out
+}
+
+// TagValue returns the value of the given key in the struct tag of the member.
+func (m *MemberDecl) TagValue(key string) (string, bool) {
+	return reflect.StructTag(m.Tag).Lookup(key)
+}
+
+// HasAnnotation returns true if the member has the named Annotation.
+func (m *MemberDecl) HasAnnotation(name string) bool {
+	for _, a := range m.Annotations {
+		if a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOnly returns true if the member has the @readonly Annotation.
+func (m *MemberDecl) ReadOnly() bool {
+	return m.HasAnnotation(annoReadOnly)
+}
+
+// Required returns true if the member has the @required Annotation.
+func (m *MemberDecl) Required() bool {
+	return m.HasAnnotation(annoRequired)
+}
+
+// NoCopy returns true if the member has the @nocopy Annotation, or is a lock or another value of the sync or
+// sync/atomic packages, which must not be copied. Such members are left out of Clone, Equal, Hash and JSON.
+func (m *MemberDecl) NoCopy() bool {
+	typ := strings.TrimSpace(m.Type)
+	return m.HasAnnotation(annoNoCopy) || lockTypes[typ] || strings.HasPrefix(typ, "atomic.")
+}
+
+// JSONName returns the key of the member in the JSON of its object, or an empty string if the member is not
+// encoded. The name comes from the json struct tag or @json Annotation if there is one.
+func (m memberDef) JSONName() string {
+	if m.Name() == "" || m.NoCopy() {
+		return ""
+	}
+	if v, ok := m.TagValue("json"); ok {
+		name := strings.Split(v, ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return m.Name()
+}
+
+// DefaultAssign returns the statement that assigns the default value to the member variables of the object with the
+// given receiver name.
+func (m memberDef) DefaultAssign(receiver string) string {
+	var vars []string
+	for _, name := range m.Names {
+		vars = append(vars, receiver+"."+name)
+	}
+	return strings.Join(vars, ", ") + " = " + m.Default
+}
+
+// CloneExpr returns the expression that copies the member from the object with the given receiver name.
+func (m memberDef) CloneExpr(receiver string) string {
+	v := receiver + "." + m.Name()
+	switch {
+	case strings.HasPrefix(m.Type, "[]"):
+		return "gopp.CloneSlice(" + v + ")"
+	case strings.HasPrefix(m.Type, "map["):
+		return "gopp.CloneMap(" + v + ")"
+	}
+	return v
+}
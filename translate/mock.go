@@ -0,0 +1,246 @@
+package translate
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// MockOptions controls where the mocks of the classes of a file are generated.
+type MockOptions struct {
+	Package string // the name of the package of the mocks, or empty to generate them in the package of the classes
+	Import  string // the import path of the package of the classes, which is needed if Package is given
+}
+
+// GenerateMocks generates a mock of the interface of each class of the named file, for tests. The mock of a class
+// Person is a struct MockPerson that implements the whole interface of the class, including the methods of its
+// superclasses and of gopp.BaseI. Each method records the call, and calls the function in the field that is named
+// after the method followed by Func, like NameFunc, or returns zero values if the field is nil.
+//
+// The interfaces are found by type checking the generated code of the package of the file together with the other .go
+// files in its directory, so all of the files of the package should be added first. The Source of the result holds
+// the code of the mocks.
+func (s *Session) GenerateMocks(filename string, opts MockOptions) (*Result, error) {
+	tree, ok := s.files[filename]
+	if !ok {
+		return nil, fmt.Errorf("file %s was not added to the session", filename)
+	}
+	r := &Result{}
+	classes := tree.classes()
+	if len(classes) == 0 {
+		return r, nil
+	}
+	if opts.Package != "" && opts.Import == "" {
+		return nil, fmt.Errorf("the import path of package %s is needed to generate mocks in package %s",
+			classes[0].scope.name, opts.Package)
+	}
+	// Problems in any file of the package keep the interfaces from being known
+	pkg, diags := s.typeCheck(classes[0].scope)
+	if len(diags) > 0 {
+		r.Diagnostics = diags
+		return r, r.Diagnostics
+	}
+
+	m := newMockWriter(pkg, opts)
+	for _, c := range classes {
+		r.Classes = append(r.Classes, c.info())
+		if err := m.writeMock(c); err != nil {
+			r.Diagnostics = append(r.Diagnostics, diagnostic(filename, posErrorf(c.decl.Position, "%v", err)))
+		}
+	}
+	if r.Diagnostics != nil {
+		return r, r.Diagnostics
+	}
+	src, err := format.Source(m.file())
+	if err != nil {
+		// Only a bug in the mock writer can make code that cannot be formatted
+		r.Diagnostics = append(r.Diagnostics, Diagnostic{File: filename, Message: "mock: " + err.Error()})
+		return r, r.Diagnostics
+	}
+	r.Source = src
+	return r, nil
+}
+
+// MockName returns the name of the test file that holds the mocks of the classes of a .gpp file, in the package of the
+// classes, which is the name of the generated .go file followed by _mock_test.go, like person_mock_test.go.
+func MockName(goFile string) string {
+	return strings.TrimSuffix(goFile, ".go") + "_mock_test.go"
+}
+
+// mockWriter writes the mocks of the classes of a file.
+type mockWriter struct {
+	pkg     *types.Package // the package of the classes
+	opts    MockOptions
+	imports map[string]string // the names of the packages that the mocks use, by import path
+	code    bytes.Buffer
+}
+
+func newMockWriter(pkg *types.Package, opts MockOptions) *mockWriter {
+	m := &mockWriter{pkg: pkg, opts: opts, imports: map[string]string{"sync": "sync"}}
+	if opts.Package != "" {
+		m.imports[opts.Import] = pkg.Name()
+	}
+	return m
+}
+
+// qualifier returns the name that qualifies the types of a package in the mocks.
+func (m *mockWriter) qualifier(p *types.Package) string {
+	if p == m.pkg {
+		if m.opts.Package == "" {
+			return ""
+		}
+		return p.Name()
+	}
+	m.imports[p.Path()] = p.Name()
+	return p.Name()
+}
+
+// typeString returns a type as it is written in the mocks.
+func (m *mockWriter) typeString(t types.Type) string {
+	return types.TypeString(t, m.qualifier)
+}
+
+// writeMock writes the mock of the interface of a class.
+func (m *mockWriter) writeMock(c *classDef) error {
+	obj, ok := m.pkg.Scope().Lookup(c.Interface).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("class %s: interface %s not found", c.Name, c.Interface)
+	}
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return fmt.Errorf("class %s: %s is not an interface", c.Name, c.Interface)
+	}
+
+	name := "Mock" + c.Name
+	call := name + "Call"
+	ifaceName := m.typeString(obj.Type())
+
+	// The names of the fields and methods of the mock, which the methods of the interface cannot have
+	used := map[string]string{"Calls": "method", "CallsTo": "method", "record": "method", "mu": "field", "calls": "field"}
+	var methods []*types.Func
+	for i := 0; i < iface.NumMethods(); i++ {
+		f := iface.Method(i)
+		if !f.Exported() && m.opts.Package != "" {
+			return fmt.Errorf("class %s: the mock of %s cannot be in another package, because method %s is not exported",
+				c.Name, c.Interface, f.Name())
+		}
+		methods = append(methods, f)
+	}
+	for _, f := range methods {
+		for _, n := range []string{f.Name(), f.Name() + "Func"} {
+			if used[n] != "" {
+				return fmt.Errorf("class %s: method %s conflicts with the %s %s of the mock", c.Name, f.Name(), used[n], n)
+			}
+			used[n] = "method"
+			if n != f.Name() {
+				used[n] = "field"
+			}
+		}
+	}
+
+	w := &m.code
+	fmt.Fprintf(w, "\n// %s is a mock of %s for tests. Each method records the call, and calls the function in\n", name, ifaceName)
+	fmt.Fprintf(w, "// the field of the same name followed by Func if it is set, or returns zero values if it is not.\n")
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for _, f := range methods {
+		sig := f.Type().(*types.Signature)
+		fn := types.NewSignatureType(nil, nil, nil, sig.Params(), sig.Results(), sig.Variadic())
+		fmt.Fprintf(w, "\t%sFunc %s\n", f.Name(), m.typeString(fn))
+	}
+	fmt.Fprintf(w, "\n\tmu    sync.Mutex\n\tcalls []%s\n}\n\n", call)
+	fmt.Fprintf(w, "// %s is a call of a method of %s.\n", call, name)
+	fmt.Fprintf(w, "type %s struct {\n\tMethod string\n\tArgs   []interface{}\n}\n\n", call)
+	fmt.Fprintf(w, "var _ %s = (*%s)(nil)\n", ifaceName, name)
+
+	for _, f := range methods {
+		m.writeMethod(name, f)
+	}
+
+	fmt.Fprintf(w, "\n// Calls returns the calls of the methods of the mock, in the order they were made.\n")
+	fmt.Fprintf(w, "func (m *%s) Calls() []%s {\n", name, call)
+	fmt.Fprintf(w, "\tm.mu.Lock()\n\tdefer m.mu.Unlock()\n\treturn append([]%s(nil), m.calls...)\n}\n", call)
+	fmt.Fprintf(w, "\n// CallsTo returns the calls of the named method, in the order they were made.\n")
+	fmt.Fprintf(w, "func (m *%s) CallsTo(method string) []%s {\n", name, call)
+	fmt.Fprintf(w, "\tm.mu.Lock()\n\tdefer m.mu.Unlock()\n\tvar calls []%s\n", call)
+	fmt.Fprintf(w, "\tfor _, c := range m.calls {\n\t\tif c.Method == method {\n")
+	fmt.Fprintf(w, "\t\t\tcalls = append(calls, c)\n\t\t}\n\t}\n\treturn calls\n}\n")
+	fmt.Fprintf(w, "\nfunc (m *%s) record(method string, args ...interface{}) {\n", name)
+	fmt.Fprintf(w, "\tm.mu.Lock()\n\tdefer m.mu.Unlock()\n\tm.calls = append(m.calls, %s{method, args})\n}\n", call)
+	return nil
+}
+
+// writeMethod writes a method of a mock, which records the call and calls the function of the method.
+func (m *mockWriter) writeMethod(mock string, f *types.Func) {
+	sig := f.Type().(*types.Signature)
+	var params, args, results []string
+	for i := 0; i < sig.Params().Len(); i++ {
+		arg := fmt.Sprintf("p%d", i)
+		t := m.typeString(sig.Params().At(i).Type())
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			t = "..." + strings.TrimPrefix(t, "[]")
+			args = append(args, arg+"...")
+		} else {
+			args = append(args, arg)
+		}
+		params = append(params, arg+" "+t)
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		results = append(results, fmt.Sprintf("r%d %s", i, m.typeString(sig.Results().At(i).Type())))
+	}
+
+	w := &m.code
+	fmt.Fprintf(w, "\nfunc (m *%s) %s(%s)", mock, f.Name(), strings.Join(params, ", "))
+	if results != nil {
+		fmt.Fprintf(w, " (%s)", strings.Join(results, ", "))
+	}
+	fmt.Fprintf(w, " {\n\tm.record(%q", f.Name())
+	for _, arg := range args {
+		fmt.Fprintf(w, ", %s", strings.TrimSuffix(arg, "..."))
+	}
+	fmt.Fprintf(w, ")\n\tif m.%sFunc != nil {\n\t\t", f.Name())
+	if results != nil {
+		fmt.Fprintf(w, "return ")
+	}
+	fmt.Fprintf(w, "m.%sFunc(%s)\n\t}\n", f.Name(), strings.Join(args, ", "))
+	if results != nil {
+		fmt.Fprintf(w, "\treturn\n")
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+// file returns the code of the file that holds the mocks, which is not formatted.
+func (m *mockWriter) file() []byte {
+	pkg := m.opts.Package
+	if pkg == "" {
+		pkg = m.pkg.Name()
+	}
+	var paths []string
+	for path := range m.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// The standard library is imported first, separated from the other packages
+	sort.SliceStable(paths, func(i, j int) bool {
+		return !strings.Contains(paths[i], ".") && strings.Contains(paths[j], ".")
+	})
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%spackage %s\n\nimport (\n", generatedHeader, pkg)
+	for i, path := range paths {
+		if i > 0 && strings.Contains(path, ".") && !strings.Contains(paths[i-1], ".") {
+			out.WriteString("\n")
+		}
+		if m.imports[path] != path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&out, "\t%s %q\n", m.imports[path], path)
+		} else {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+	}
+	fmt.Fprintf(&out, ")\n")
+	out.Write(m.code.Bytes())
+	return out.Bytes()
+}
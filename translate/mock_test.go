@@ -0,0 +1,68 @@
+package translate
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateMocks(t *testing.T) {
+	src := `package zoo
+
+import "io"
+
+class Animal extends gopp.Base {
+	func Speak(w io.Writer, words ...string) (int, error) {
+		return 0, nil
+	}
+}
+
+class Dog extends Animal {
+	func Fetch() {
+	}
+}
+`
+	file := filepath.Join(t.TempDir(), "animal.gpp")
+	s := NewSession(Options{})
+	if err := s.Add(file, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.GenerateMocks(file, MockOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package zoo\n",
+		"import (\n\t\"io\"\n\t\"sync\"\n\n\t\"github.com/spekary/gopp\"\n)",
+		"type MockDog struct {",
+		"\tSpeakFunc      func(w io.Writer, words ...string) (int, error)\n",
+		"\tCloneFunc      func() gopp.BaseI\n",
+		"func (m *MockDog) Speak(p0 io.Writer, p1 ...string) (r0 int, r1 error) {\n\tm.record(\"Speak\", p0, p1)\n" +
+			"\tif m.SpeakFunc != nil {\n\t\treturn m.SpeakFunc(p0, p1...)\n\t}\n\treturn\n}",
+		"func (m *MockDog) Fetch() {\n\tm.record(\"Fetch\")\n\tif m.FetchFunc != nil {\n\t\tm.FetchFunc()\n\t}\n}",
+		"var _ DogI = (*MockDog)(nil)",
+	} {
+		if !strings.Contains(string(r.Source), want) {
+			t.Errorf("Missing %q in:\n%s", want, r.Source)
+		}
+	}
+	if strings.Contains(string(r.Source), "func (m *MockAnimal) Fetch") {
+		t.Error("Method of a subclass in the mock of its superclass")
+	}
+
+	r, err = s.GenerateMocks(file, MockOptions{Package: "mocks", Import: "example.com/zoo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"package mocks\n", "\t\"example.com/zoo\"\n", "var _ zoo.DogI = (*MockDog)(nil)"} {
+		if !strings.Contains(string(r.Source), want) {
+			t.Errorf("Missing %q in:\n%s", want, r.Source)
+		}
+	}
+
+	// A method whose name is used by the mock
+	s.Add(filepath.Join(filepath.Dir(file), "cat.gpp"), []byte("package zoo\n\nclass Cat extends Animal {\n\tfunc Calls() {\n\t}\n}\n"))
+	if _, err := s.GenerateMocks(filepath.Join(filepath.Dir(file), "cat.gpp"), MockOptions{}); err == nil {
+		t.Error("Conflict with the Calls method of the mock not reported")
+	}
+}
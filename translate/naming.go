@@ -0,0 +1,125 @@
+package translate
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// Naming controls the names of the Go types and functions that are generated for a class. Each name is a pattern, in
+// which {Name} is replaced by the name of the class, {name} by the name of the class with a lower case first letter,
+// and {n} by the lower case first letter of the name of the class. An empty pattern uses the default.
+type Naming struct {
+	Interface   string // the interface of the class, "{Name}I" by default
+	Struct      string // the struct of the class, "{Name}" by default
+	Constructor string // the function that creates an object of the class, "New{Name}" by default
+	Receiver    string // the receiver of the methods of the class, "{n}_" by default
+}
+
+// DefaultNaming returns the names that gopp generates if they are not configured.
+func DefaultNaming() Naming {
+	return Naming{
+		Interface:   "{Name}I",
+		Struct:      "{Name}",
+		Constructor: "New{Name}",
+		Receiver:    "{n}_",
+	}
+}
+
+// withDefaults returns the naming with the empty patterns replaced by the default patterns.
+func (n Naming) withDefaults() Naming {
+	d := DefaultNaming()
+	if n.Interface == "" {
+		n.Interface = d.Interface
+	}
+	if n.Struct == "" {
+		n.Struct = d.Struct
+	}
+	if n.Constructor == "" {
+		n.Constructor = d.Constructor
+	}
+	if n.Receiver == "" {
+		n.Receiver = d.Receiver
+	}
+	return n
+}
+
+// Validate returns an error if a pattern uses a placeholder that does not exist, or does not give a valid Go name.
+func (n Naming) Validate() error {
+	_, err := n.names("Thing")
+	return err
+}
+
+// classNames are the names generated for a class.
+type classNames struct {
+	Interface   string
+	Struct      string
+	Constructor string
+	Receiver    string
+}
+
+// names returns the names generated for the named class, or an error if one of them is not a valid name.
+func (n Naming) names(class string) (classNames, error) {
+	n = n.withDefaults()
+	var names classNames
+	var err error
+	for _, p := range []struct {
+		field   string
+		pattern string
+		name    *string
+	}{
+		{"interface", n.Interface, &names.Interface},
+		{"struct", n.Struct, &names.Struct},
+		{"constructor", n.Constructor, &names.Constructor},
+		{"receiver", n.Receiver, &names.Receiver},
+	} {
+		if *p.name, err = expandName(p.pattern, class); err != nil {
+			return names, fmt.Errorf("%s name %q: %v", p.field, p.pattern, err)
+		}
+		if !token.IsIdentifier(*p.name) {
+			return names, fmt.Errorf("%s name %q gives %s for class %s, which is not a valid name",
+				p.field, p.pattern, *p.name, class)
+		}
+	}
+	if names.Receiver == "this" || names.Receiver == "gopp" {
+		// The bodies of methods use this for the object, and the generated code uses the gopp package.
+		return names, fmt.Errorf("receiver name %q gives %s for class %s, which is reserved",
+			n.Receiver, names.Receiver, class)
+	}
+	if names.Interface == names.Struct {
+		return names, fmt.Errorf("the interface and struct of class %s are both named %s", class, names.Struct)
+	}
+	return names, nil
+}
+
+// expandName replaces the placeholders in a naming pattern with the name of a class.
+func expandName(pattern string, class string) (string, error) {
+	var out strings.Builder
+	for pattern != "" {
+		i := strings.IndexByte(pattern, '{')
+		if i < 0 {
+			out.WriteString(pattern)
+			break
+		}
+		out.WriteString(pattern[:i])
+		pattern = pattern[i:]
+		j := strings.IndexByte(pattern, '}')
+		if j < 0 {
+			return "", fmt.Errorf("missing }")
+		}
+		switch pattern[:j+1] {
+		case "{Name}":
+			out.WriteString(class)
+		case "{name}":
+			out.WriteString(lowerFirst(class))
+		case "{n}":
+			_, size := utf8.DecodeRuneInString(class)
+			out.WriteString(lowerFirst(class[:size]))
+		default:
+			return "", fmt.Errorf("unknown placeholder %s", pattern[:j+1])
+		}
+		pattern = pattern[j+1:]
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,628 @@
+package translate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ast is a file ready for code generation, which is a mix of text that is output as is and classes.
+type ast []fmt.Stringer
+
+type funcDef struct {
+	Name          string
+	Params        string
+	Body          string
+	ProcessedBody string
+	Comment       string
+	IsOverride    bool
+	Line          int // the line of the method in the .gpp file
+}
+
+type classDef struct {
+	Name              string
+	Extends           string
+	ConstructorParams string // the parameters of the Construct function of the class, without the parentheses
+	HasConstructor    bool   // true if the class has its own Construct function
+	NewParams         string // the parameters of the New function, which can come from a superclass' Construct function
+	Members           []memberDef
+	Funcs             []funcDef
+	Comment           string
+	ParentVarList     string
+	Interface         string // the name of the interface of the class
+	Struct            string // the name of the struct of the class
+	Constructor       string // the name of the function that creates an object of the class
+	Receiver          string
+	Parent            string // the name of the embedded struct of the superclass
+	ParentStruct      string // the struct of the superclass, qualified with its package
+	ParentInterface   string // the interface of the superclass, qualified with its package
+	JSON              bool
+	Equatable         bool   // true if the class or one of its superclasses is marked equatable
+	EqualType         string // the interface that the Equal function takes
+	EqualChain        bool   // true if the Equal function calls the superclass' Equal function
+	inheritedEqual    string // the interface that the Equal function inherited from a superclass in another package takes
+
+	decl  *ClassDecl // the declaration of the class
+	file  string     // the file the class is declared in
+	scope *pkgScope  // the package the class is declared in
+}
+
+// Fields returns the member variables of the class, with one memberDef for each name. Members that declare several
+// names are split, and embedded types have the name of the type without its package, as in Go.
+func (c *classDef) Fields() []memberDef {
+	var fields []memberDef
+	for _, m := range c.Members {
+		names := m.Names
+		if len(names) == 0 {
+			names = []string{embeddedName(m.Type)}
+		}
+		for _, name := range names {
+			fields = append(fields, memberDef{m.MemberDecl, name})
+		}
+	}
+	return fields
+}
+
+// ValueFields returns the Fields that Clone copies and that Equal and Hash compare and hash, which leaves out the
+// members that must not be copied.
+func (c *classDef) ValueFields() []memberDef {
+	var fields []memberDef
+	for _, f := range c.Fields() {
+		if !f.NoCopy() {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// ConvertDefault returns the statement that sets the default value of the member m, with references to "this"
+// converted the same way as in method bodies.
+func (c *classDef) ConvertDefault(m memberDef) string {
+	return convertBody(m.DefaultAssign("this"), c)
+}
+
+// HasDefaults returns true if a member of the class has a default value.
+func (c *classDef) HasDefaults() bool {
+	for _, m := range c.Members {
+		if m.Default != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// modifiers that can follow the superclass in a class definition
+const (
+	modEquatable = "equatable" // generate Equal and Hash functions
+)
+
+// goppImportPath is the import path of the gopp package, which holds the Base class.
+const goppImportPath = "github.com/spekary/gopp"
+
+// packageClause matches the line of a package clause.
+var packageClause = regexp.MustCompile(`(?m)^[ \t]*package[ \t]+\w+[^\n]*\n`)
+
+// posError is an error at a position in a .gpp file.
+type posError struct {
+	pos Position
+	msg string
+}
+
+func posErrorf(pos Position, format string, args ...interface{}) error {
+	return &posError{pos, fmt.Sprintf(format, args...)}
+}
+
+func (e *posError) Error() string {
+	return e.pos.String() + ": " + e.msg
+}
+
+// diagnostic returns the Diagnostic that describes an error in the named file.
+func diagnostic(file string, err error) Diagnostic {
+	if e, ok := err.(*posError); ok {
+		return Diagnostic{File: file, Line: e.pos.Line, Column: e.pos.Column, Message: e.msg}
+	}
+	return Diagnostic{File: file, Message: err.Error()}
+}
+
+func (a ast) String() string {
+	var out string
+
+	for _, s := range a {
+		out += s.String()
+	}
+
+	return out
+}
+
+// ParseFile parses the .gpp source code in src, which comes from the named file, and returns its AST. Only the
+// syntax of the file is checked, so the AST can be inspected even if the file would not translate. The returned
+// error is a Diagnostics.
+func ParseFile(filename string, src []byte) (*File, error) {
+	f, err := parseFile(filename, string(src))
+	if err != nil {
+		return nil, Diagnostics{diagnostic(filename, err)}
+	}
+	return f, nil
+}
+
+func parseFile(filename string, input string) (*File, error) {
+	return parse(lex(filename, input))
+}
+
+/**
+Parse the items coming from the lexer into the AST of a file. Text outside of classes is collected into GoDecls,
+joining text that is next to each other, and a comment just before a class becomes the comment of the class.
+*/
+func parse(l *lexer) (f *File, err error) {
+	var comment string
+	var commentPos Pos
+	var isPackage bool
+
+	defer func() {
+		if err != nil {
+			l.drain()
+		}
+	}()
+
+	f = &File{Name: l.name}
+
+	addText := func(pos Pos, text string) {
+		if n := len(f.Decls); n > 0 {
+			if d, ok := f.Decls[n-1].(*GoDecl); ok {
+				d.Text += text
+				return
+			}
+		}
+		f.Decls = append(f.Decls, &GoDecl{Position: l.position(pos), Text: text})
+	}
+
+forloop:
+	for {
+		item := l.nextItem()
+
+		switch item.typ {
+		case itemText:
+			if isPackage {
+				if fields := strings.Fields(item.val); len(fields) > 0 {
+					f.Package = fields[0]
+				}
+				isPackage = false
+			}
+			if comment != "" {
+				addText(commentPos, comment)
+				comment = ""
+			}
+			addText(item.pos, item.val)
+		case itemClass:
+			var c *ClassDecl
+			if c, err = parseClass(item, l, comment); err != nil {
+				return nil, err
+			}
+			f.Decls = append(f.Decls, c)
+			comment = ""
+		case itemError:
+			return nil, &posError{l.position(item.pos), item.val}
+		case itemComment, itemLineComment:
+			if comment == "" {
+				commentPos = item.pos
+			}
+			comment += item.val
+		case itemEOF:
+			if len(comment) > 0 {
+				addText(commentPos, comment)
+			}
+			break forloop
+
+		case itemPackage:
+			if comment != "" {
+				addText(commentPos, comment)
+				comment = ""
+			}
+			addText(item.pos, item.val)
+			isPackage = true
+		default:
+			return nil, posErrorf(l.position(item.pos), "unexpected token: %v", item)
+		}
+	}
+
+	return f, nil
+}
+
+// unexpectedEOF returns the error for a file that ends in the middle of a class.
+func unexpectedEOF(l *lexer, item item) error {
+	return &posError{l.position(item.pos), "unexpected EOF"}
+}
+
+func parseClass(nameItem item, l *lexer, comment string) (*ClassDecl, error) {
+	var curComment string
+	className := nameItem.val
+	class := &ClassDecl{
+		Position: l.position(nameItem.pos),
+		Comment:  comment,
+		Name:     className,
+	}
+
+	item := l.nextItem()
+
+	switch item.typ {
+	case itemEOF:
+		return nil, unexpectedEOF(l, item)
+	case itemError:
+		return nil, &posError{l.position(item.pos), item.val}
+	case itemExtends:
+		class.Extends = item.val
+		// keep going
+	default:
+		return nil, posErrorf(l.position(item.pos), "class %s: extends keyword expected, got %v", className, item)
+	}
+
+modloop:
+	for {
+		item = l.nextItem()
+
+		switch item.typ {
+		case itemEOF:
+			return nil, unexpectedEOF(l, item)
+		case itemError:
+			return nil, &posError{l.position(item.pos), item.val}
+		case itemClassModifier:
+			class.Modifiers = append(class.Modifiers, item.val)
+		case itemLeftDelim:
+			break modloop
+		default:
+			return nil, posErrorf(l.position(item.pos), "class %s: left delimiter expected, got %v", className, item)
+		}
+	}
+
+	var modifiers []string
+	var commentPos Position
+
+forloop:
+	for {
+		item = l.nextItem()
+		switch item.typ {
+		case itemEOF:
+			return nil, unexpectedEOF(l, item)
+		case itemError:
+			return nil, &posError{l.position(item.pos), item.val}
+		case itemComment, itemLineComment:
+			if curComment == "" {
+				commentPos = l.position(item.pos)
+			} else {
+				curComment += "\n"
+			}
+			curComment += item.val
+		case itemMember:
+			m, err := parseMember(item.val, curComment)
+			if err != nil {
+				return nil, posErrorf(l.position(item.pos), "class %s: %s", className, err)
+			}
+			m.Position = l.position(item.pos)
+			m.end = l.position(item.pos + Pos(len(strings.TrimRight(item.val, " \t\r\n"))))
+			if curComment != "" {
+				m.CommentPos = commentPos
+			}
+			class.Members = append(class.Members, m)
+			curComment = ""
+		case itemOverride:
+			modifiers = append(modifiers, tokOverride)
+		case itemFunc:
+			m, err := parseFunc(item, l, curComment)
+			if err != nil {
+				return nil, err
+			}
+			m.Modifiers = modifiers
+			if curComment != "" {
+				m.CommentPos = commentPos
+			}
+			class.Methods = append(class.Methods, m)
+			curComment = ""
+			modifiers = nil
+		case itemRightDelim:
+			class.ClosingComment = curComment
+			break forloop
+		}
+	}
+
+	return class, nil
+}
+
+func parseFunc(nameItem item, l *lexer, comment string) (*MethodDecl, error) {
+	name := nameItem.val
+	params := l.nextItem()
+	if params.typ == itemError {
+		return nil, &posError{l.position(params.pos), params.val}
+	}
+	if params.typ != itemFuncParams {
+		return nil, posErrorf(l.position(params.pos), "function parameters expected for %s, got %v", name, params)
+	}
+	body := l.nextItem()
+	if body.typ == itemError {
+		return nil, &posError{l.position(body.pos), body.val}
+	}
+	if body.typ != itemFuncBody {
+		return nil, posErrorf(l.position(body.pos), "function body expected for %s, got %v", name, body)
+	}
+
+	return &MethodDecl{
+		Position: l.position(nameItem.pos),
+		Comment:  comment,
+		Name:     name,
+		Params:   strings.TrimSpace(params.val),
+		Body:     strings.TrimSpace(body.val),
+		end:      l.position(body.pos + Pos(len(body.val))),
+	}, nil
+}
+
+// newAST converts the AST of a file into the form used for code generation.
+func newAST(f *File) (ast, error) {
+	var out ast
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *GoDecl:
+			out = append(out, goText{d.Text, d.Position.Line})
+		case *ClassDecl:
+			c, err := newClassDef(d)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, c)
+		}
+	}
+	return addGoppImport(out), nil
+}
+
+// newClassDef returns the classDef used to generate the code of the class declared by d.
+func newClassDef(d *ClassDecl) (*classDef, error) {
+	c := &classDef{
+		Name:    d.Name,
+		Extends: d.Extends,
+		Comment: d.Comment,
+		decl:    d,
+	}
+	if err := c.applyNaming(Naming{}); err != nil {
+		return nil, err
+	}
+
+	for _, mod := range d.Modifiers {
+		switch mod {
+		case modEquatable:
+			c.Equatable = true
+		default:
+			return nil, posErrorf(d.Position, "class %s: unknown class modifier %s", d.Name, mod)
+		}
+	}
+
+	for _, m := range d.Members {
+		c.Members = append(c.Members, memberDef{MemberDecl: m})
+	}
+
+	for _, m := range d.Methods {
+		f := funcDef{
+			Name:       m.Name,
+			Params:     m.Params,
+			Body:       m.Body,
+			Comment:    m.Comment,
+			IsOverride: m.IsOverride(),
+			Line:       m.Position.Line,
+		}
+		// Special constructor function
+		if m.Name == "Construct" {
+			c.ConstructorParams = strings.Trim(f.Params, "( ) ")
+			c.HasConstructor = true
+			f.IsOverride = true // constructor always overrides. This means base class MUST have a Construct function.
+		}
+		c.Funcs = append(c.Funcs, f)
+	}
+	return c, nil
+}
+
+// addGoppImport adds an import of the gopp package just after the package clause if the file declares classes but
+// does not import gopp itself. Generated code refers to the gopp package, even for classes that do not directly
+// extend gopp.Base.
+func addGoppImport(a ast) ast {
+	if len(a.classes()) == 0 {
+		return a
+	}
+	for _, s := range a {
+		if v, ok := s.(goText); ok && strings.Contains(v.text, `"`+goppImportPath+`"`) {
+			return a
+		}
+	}
+	for i, s := range a {
+		if v, ok := s.(goText); ok {
+			if loc := packageClause.FindStringIndex(v.text); loc != nil {
+				// The text after the import keeps its own start line, so that the line map stays correct
+				head := goText{v.text[:loc[1]] + "\nimport \"" + goppImportPath + "\"\n", v.line}
+				tail := goText{v.text[loc[1]:], v.line + strings.Count(v.text[:loc[1]], "\n")}
+				return append(a[:i], append(ast{head, tail}, a[i+1:]...)...)
+			}
+		}
+	}
+	return a
+}
+
+// This struct will be sent in to the template to generate the go file
+type TmplClass struct {
+	Comments string
+	Params   string
+	Name     string
+	VarList  string
+	Receiver string
+	Parent   string
+	Members  []TmplMember
+	Methods  []TmplMethod
+}
+
+type TmplMember struct {
+	Comment string
+	Name    string
+}
+
+type TmplMethod struct {
+	Name   string
+	Params string
+	Body   string
+}
+
+/**
+Output the class as a combination interface and struct, using the templates of its package.
+*/
+func (c *classDef) String() string {
+	tmpl := defaultTemplate
+	if c.scope != nil {
+		if c.scope.tmplErr != nil {
+			panic(c.scope.tmplErr)
+		}
+		tmpl = c.scope.tmpl
+	}
+
+	var tpl bytes.Buffer
+
+	if err := tmpl.ExecuteTemplate(&tpl, "class", c); err != nil {
+		panic(err)
+	}
+
+	return tpl.String()
+}
+
+func (c *classDef) outFuncs() string {
+	var out string
+
+	for _, f := range c.Funcs {
+		out += "func (this *" + c.Name + ") " + f.Name + f.Params
+		out += c.processFuncBody(f)
+		out += "\n\n"
+	}
+
+	return out
+}
+
+func (c *classDef) outReflect() string {
+	parts := strings.Split(c.Extends, ".")
+	extendsName := parts[len(parts)-1]
+
+	out :=
+		`
+func (this *` + c.Name + `) IsA(className string) bool {
+	if (className == "` + c.Name + `") {
+		return true
+	}
+	return this.` + extendsName + `.IsA(className)
+}
+
+func (this *` + c.Name + `) Class() string {
+	return "` + c.Name + `"
+}
+`
+	return out
+}
+
+/**
+Takes the raw body coming from the class definition and changes it to be go compatible. Some specific things it does:
+- Strips all comments
+- Converts method calls to be called against the interface, so that they are virtually called
+- Converts template types to physical types
+*/
+func (c *classDef) processFuncBody(f funcDef) string {
+	var out string
+	var start, pos int
+
+	in := f.Body
+	length := len(in)
+
+mainfor:
+	for {
+		r, width := utf8.DecodeRuneInString(in[pos:])
+		pos += width
+		if pos >= length {
+			break
+		}
+
+		switch r {
+		case '"', '\'':
+			strLength := strings.IndexRune(in[pos:], r)
+			if strLength < 0 {
+				panic("String has open quote, but not close quote.")
+			}
+			pos += strLength + 1
+
+			out += convertBody(in[start:pos], c)
+			start = pos
+		case '/':
+			c2, width := utf8.DecodeRuneInString(in[pos:])
+			pos += width
+			if pos >= length {
+				break mainfor
+			}
+
+			if c2 == '/' {
+				out += convertBody(in[start:pos-2], c) // send out current stuff, and then skip the rest of the comment
+				commentLength := strings.Index(in[pos:], "\n")
+				start = pos + commentLength + 1
+				pos = start
+			} else if c2 == '*' {
+				out += convertBody(in[start:pos-2], c) // send out current stuff, and then skip the rest of the comment
+				commentLength := strings.Index(in[pos:], "*/")
+				start = pos + commentLength + 2
+				pos = start
+			}
+		}
+		if pos >= length {
+			break
+		}
+
+	}
+
+	out += convertBody(in[start:pos], c)
+
+	out = strings.Trim(out, "{} ")
+	out = strings.TrimSpace(out)
+	return out
+}
+
+func convertBody(in string, c *classDef) string {
+	rFunc, _ := regexp.Compile("this\\.([a-zA-Z0-9_]+) ?\\(")
+	out := rFunc.ReplaceAllString(in, c.Receiver+".I().("+c.Interface+").$1(")
+	out = strings.Replace(out, "parent::", c.Receiver+"."+c.Parent+".", -1)
+	out = strings.Replace(out, "this.", c.Receiver+".", -1)
+	out = strings.Replace(out, "this", c.Receiver+".I().("+c.Interface+")", -1)
+	return out
+}
+
+// applyNaming sets the names of the types and functions generated for the class and its superclass. The classes of
+// the gopp package always have the default names, and classes in other packages are assumed to use the same naming as
+// the class.
+func (c *classDef) applyNaming(n Naming) error {
+	names, err := n.names(c.Name)
+	if err != nil {
+		return posErrorf(c.decl.Position, "%v", err)
+	}
+	c.Interface, c.Struct, c.Constructor, c.Receiver = names.Interface, names.Struct, names.Constructor, names.Receiver
+
+	pkg, parent := "", c.Extends
+	if i := strings.LastIndex(c.Extends, "."); i >= 0 {
+		pkg, parent = c.Extends[:i+1], c.Extends[i+1:]
+	}
+	if pkg == "gopp." {
+		n = DefaultNaming()
+	}
+	names, err = n.names(parent)
+	if err != nil {
+		return posErrorf(c.decl.Position, "superclass %s: %v", c.Extends, err)
+	}
+	c.Parent = names.Struct
+	c.ParentStruct = pkg + names.Struct
+	c.ParentInterface = pkg + names.Interface
+	return nil
+}
+
+// goText is Go code that is output as it is, which starts at the given line of the .gpp file.
+type goText struct {
+	text string
+	line int
+}
+
+func (t goText) String() string { return t.text }
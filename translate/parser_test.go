@@ -0,0 +1,277 @@
+package translate
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	s :=
+		`
+/**
+Test comment
+ */
+`
+	sNew, err := ProcessString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sNew != s {
+		t.Error("Comment not passed through: " + sNew)
+	}
+}
+
+func TestText2(t *testing.T) {
+	s :=
+		`
+/**
+Test comment
+ */
+
+ type test struct  {
+ 	blah
+ }
+`
+	sNew, err := ProcessString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sNew != s {
+		t.Error("Text not passed through: " + sNew)
+	}
+}
+
+func TestSimple(t *testing.T) {
+	s :=
+		`
+class Test extends Base {
+	testMember string
+
+	func _construct() {
+		this.Base._construct()
+		this.testMember = "hi"
+	}
+}
+`
+	sExpected :=
+		`
+type Test interface {
+	Base
+}
+
+type Test_ struct {
+	Base_
+	this Test
+	testMember string
+}
+
+func NewTest() Test {
+	s := Test_{}
+	s.this := Test(&s)
+	s._construct()
+	return i
+}
+`
+	sNew, err := ProcessString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sNew != sExpected {
+		t.Error("struct with member not created: " + sNew)
+	}
+}
+
+func TestJustMember(t *testing.T) {
+	s :=
+		`
+class Test extends Base {
+	testMember string
+}
+`
+	sExpected :=
+		`
+type Test interface {
+	Base
+}
+
+type _Test struct {
+	_Base
+	testMember string
+}
+`
+	sNew, err := ProcessString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sNew != sExpected {
+		t.Error("struct with member not created: " + sNew)
+	}
+}
+
+func TestGoppImport(t *testing.T) {
+	s :=
+		`package test
+
+class Test extends gopp.Base {
+	testMember string
+}
+`
+	sNew, err := ProcessString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sNew, "package test\n\nimport \"github.com/spekary/gopp\"\n") {
+		t.Error("gopp import not added: " + sNew)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	m, err := parseMember(`name string @json("title") @readonly`, "")
+	if err != nil || !m.ReadOnly() || (memberDef{MemberDecl: m}).JSONName() != "title" {
+		t.Errorf("Annotations not parsed: %v %v", m, err)
+	}
+	if _, err = parseMember("name string `json:\"a\"` @json(\"b\")", ""); err == nil {
+		t.Error("Repeated tag key not reported")
+	}
+	if _, err = parseMember("name string `json:a`", ""); err == nil {
+		t.Error("Bad struct tag not reported")
+	}
+
+	values := []struct {
+		decl  string
+		value string
+		ok    bool
+	}{
+		{`a int @tag("a)b")`, "a)b", true},
+		{`a int @tag( "a\")" ) // (c)`, `a")`, true},
+		{"a int @tag(`x)`) = 5", "x)", true},
+		{`a int @tag(a)`, "", false},
+		{`a int @tag("a)b"`, "", false},
+		{`a int @tag("a)`, "", false},
+	}
+	for _, test := range values {
+		m, err := parseMember(test.decl, "")
+		if (err == nil) != test.ok {
+			t.Errorf("%q: wrong error %v", test.decl, err)
+		} else if err == nil && (len(m.Annotations) != 1 || m.Annotations[0].Value != test.value) {
+			t.Errorf("%q: wrong annotations %+v", test.decl, m.Annotations)
+		}
+	}
+}
+
+func TestMemberForms(t *testing.T) {
+	tests := []struct {
+		decl        string
+		names       []string
+		typ         string
+		tag         string
+		lineComment string
+	}{
+		{"x, y int", []string{"x", "y"}, "int", "", ""},
+		{"sync.Mutex", nil, "sync.Mutex", "", ""},
+		{"*Thing // embedded", nil, "*Thing", "", "// embedded"},
+		{"a [3]int `json:\"a\"`", []string{"a"}, "[3]int", `json:"a"`, ""},
+		{"s struct {\n\t\ta int `json:\"a\"` // @a\n\t} @json(\"s\")", []string{"s"}, "struct {\n\t\ta int `json:\"a\"` // @a\n\t}", `json:"s"`, ""},
+		{"f func(\n\t\ta int,\n\t) error // callback", []string{"f"}, "func(\n\t\ta int,\n\t) error", "", "// callback"},
+	}
+	for _, test := range tests {
+		m, err := parseMember(test.decl, "")
+		if err != nil {
+			t.Errorf("%q: %v", test.decl, err)
+			continue
+		}
+		if strings.Join(m.Names, ",") != strings.Join(test.names, ",") || m.Type != test.typ ||
+			m.Tag != test.tag || m.LineComment != test.lineComment {
+			t.Errorf("%q parsed as %#v", test.decl, m)
+		}
+	}
+}
+
+func TestForwardReference(t *testing.T) {
+	s :=
+		`package test
+
+class Student extends Person {
+}
+
+class Person extends gopp.Base {
+	func Construct(first string) {
+	}
+}
+`
+	sNew, err := ProcessString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sNew, "func NewStudent (first string) StudentI {") ||
+		!strings.Contains(sNew, "gopp_obj.Construct(first)") {
+		t.Error("Constructor not inherited from superclass declared later: " + sNew)
+	}
+}
+
+func TestInheritanceCycle(t *testing.T) {
+	s :=
+		`package test
+
+class CycleA extends CycleB {
+}
+
+class CycleB extends CycleA {
+}
+`
+	_, err := ProcessString(s)
+	if err == nil || !strings.Contains(err.Error(), "CycleA extends CycleB extends CycleA") {
+		t.Error("Inheritance cycle not reported: ", err)
+	}
+}
+
+func TestPackageScopes(t *testing.T) {
+	s := NewSession(Options{})
+	if _, err := s.parseString("a/a.gpp", "package a\n\nclass Parent extends gopp.Base {\n\tfunc Construct(x int) {\n\t}\n}\n"); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := s.parseString("b/b.gpp", "package b\n\nclass Child extends Parent {\n}\n\nclass Other extends gopp.Base {\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _, err := s.generate(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "x int") {
+		t.Error("Class resolved against a superclass in another package: " + out)
+	}
+	if _, err = s.parseString("b/b2.gpp", "package b\n\nclass Other extends gopp.Base {\n}\n"); err == nil {
+		t.Error("Class declared twice in a package not reported")
+	}
+}
+
+func TestConcurrentProcessString(t *testing.T) {
+	s :=
+		`package test
+
+class Test extends gopp.Base {
+	func Construct(a int) {
+	}
+}
+
+class Sub extends Test {
+}
+`
+	expected, err := ProcessString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out, err := ProcessString(s); err != nil || out != expected {
+				t.Error("Concurrent call gave different output", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,287 @@
+package translate
+
+import (
+	"bytes"
+	goast "go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// parentPlaceholder returns the text that stands in for "parent::" while a method body is formatted, since "parent::"
+// is not Go syntax. It is an identifier that the body does not use, followed by a dot, and has the same length as
+// "parent::", so that it does not change the alignment of the code around it.
+func parentPlaceholder(body string) (string, bool) {
+	const chars = "_0123456789abcdefghijklmnopqrstuvwxyz"
+	for _, c := range chars {
+		for _, d := range chars {
+			ident := "paren" + string(c) + string(d)
+			if !strings.Contains(body, ident) {
+				return ident + ".", true
+			}
+		}
+	}
+	return "", false
+}
+
+// Format formats the .gpp source code in src, which comes from the named file, in the canonical gopp style. Go code
+// outside of classes and the parameters and bodies of methods are formatted the same way as gofmt, members are
+// aligned, and comments are indented with the code they belong to. The returned error is a Diagnostics.
+func Format(filename string, src []byte) ([]byte, error) {
+	f, err := ParseFile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = Fprint(&buf, f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Fprint writes the .gpp source code of the file f to w in the canonical gopp style. The returned error is a
+// Diagnostics if part of the file is not valid Go code.
+func Fprint(w io.Writer, f *File) error {
+	var buf bytes.Buffer
+	attached := false // true if the previous text ends with a comment that is just before a class
+	for _, d := range f.Decls {
+		var text string
+		var err error
+		switch d := d.(type) {
+		case *GoDecl:
+			text, err = formatGo(d.Text)
+		case *ClassDecl:
+			text, err = formatClass(d)
+		}
+		if err != nil {
+			return Diagnostics{diagnostic(f.Name, err)}
+		}
+		if text == "" {
+			continue
+		}
+		if buf.Len() > 0 && !attached {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(text)
+		buf.WriteString("\n")
+
+		attached = false
+		if d, ok := d.(*GoDecl); ok {
+			trimmed := strings.TrimRight(d.Text, " \t\r\n")
+			lastLine := strings.TrimSpace(trimmed[strings.LastIndex(trimmed, "\n")+1:])
+			attached = (strings.HasSuffix(trimmed, rightComment) || strings.HasPrefix(lastLine, lineComment)) &&
+				strings.Count(d.Text[len(trimmed):], "\n") < 2
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// formatGo formats Go code that is outside of classes, which can be part of a file. Code that gofmt cannot parse on
+// its own, like a lone comment, is only trimmed.
+func formatGo(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", nil
+	}
+	out, err := format.Source([]byte(text))
+	if err != nil {
+		return text, nil
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func formatClass(c *ClassDecl) (string, error) {
+	var buf bytes.Buffer
+
+	if c.Comment != "" {
+		buf.WriteString(formatComment(c.Comment, ""))
+	}
+	buf.WriteString("class " + c.Name + " extends " + c.Extends)
+	for _, mod := range c.Modifiers {
+		buf.WriteString(" " + mod)
+	}
+	buf.WriteString(" {\n")
+
+	// Runs of members that are on one line each are aligned in columns, the same way gofmt aligns struct fields
+	var cols bytes.Buffer
+	tw := tabwriter.NewWriter(&cols, 0, 8, 1, ' ', tabwriter.DiscardEmptyColumns)
+	flush := func() {
+		tw.Flush()
+		for _, line := range strings.SplitAfter(cols.String(), "\n") {
+			if line != "" {
+				buf.WriteString("\t" + line)
+			}
+		}
+		cols.Reset()
+	}
+	var prevEnd Position
+	for i, e := range c.Elems() {
+		start := e.Pos()
+		var comment string
+		switch e := e.(type) {
+		case *MemberDecl:
+			comment = e.Comment
+			if e.Comment != "" && e.CommentPos.IsValid() {
+				start = e.CommentPos
+			}
+		case *MethodDecl:
+			comment = e.Comment
+			if e.Comment != "" && e.CommentPos.IsValid() {
+				start = e.CommentPos
+			}
+		}
+		if i > 0 && prevEnd.IsValid() && start.Line > prevEnd.Line+1 {
+			flush()
+			buf.WriteString("\n")
+		}
+		if comment != "" {
+			flush()
+			buf.WriteString(formatComment(comment, "\t"))
+		}
+
+		switch e := e.(type) {
+		case *MemberDecl:
+			line := formatMember(e)
+			if strings.Contains(line, "\n") {
+				flush()
+				buf.WriteString("\t" + line + "\n")
+			} else {
+				io.WriteString(tw, line+"\n")
+			}
+			prevEnd = e.End()
+		case *MethodDecl:
+			flush()
+			s, err := formatMethod(e)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(s)
+			prevEnd = e.End()
+		}
+	}
+	flush()
+
+	if c.ClosingComment != "" {
+		buf.WriteString(formatComment(c.ClosingComment, "\t"))
+	}
+	buf.WriteString("}")
+	return buf.String(), nil
+}
+
+// formatComment indents each line of a comment, and ends it with a newline. The lines inside of a block comment are
+// left as they are.
+func formatComment(comment string, indent string) string {
+	var out string
+	inBlock := false
+	for _, line := range strings.Split(comment, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inBlock {
+			out += strings.TrimRight(line, " \t") + "\n"
+		} else {
+			out += indent + trimmed + "\n"
+			inBlock = strings.HasPrefix(trimmed, leftComment)
+		}
+		if inBlock && strings.Contains(trimmed, rightComment) {
+			inBlock = false
+		}
+	}
+	return out
+}
+
+// formatMember returns the declaration of a member with tabs between the columns that are aligned.
+func formatMember(m *MemberDecl) string {
+	var cols []string
+	if len(m.Names) > 0 {
+		cols = append(cols, strings.Join(m.Names, ", "))
+	}
+	cols = append(cols, formatType(m.Type))
+
+	var rest []string
+	if m.RawTag != "" {
+		rest = append(rest, "`"+m.RawTag+"`")
+	}
+	for _, a := range m.Annotations {
+		s := "@" + a.Name
+		if a.HasValue {
+			s += "(" + strconv.Quote(a.Value) + ")"
+		}
+		rest = append(rest, s)
+	}
+	if m.Default != "" {
+		rest = append(rest, "= "+m.Default)
+	}
+	if len(rest) > 0 {
+		cols = append(cols, strings.Join(rest, " "))
+	}
+	if m.LineComment != "" {
+		cols = append(cols, m.LineComment)
+	}
+	return strings.Join(cols, "\t")
+}
+
+// formatType formats the type of a member, which is left as it is if it is not a valid Go type.
+func formatType(typ string) string {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\ntype _ "+typ, parser.ParseComments)
+	if err != nil {
+		return typ
+	}
+	spec := f.Decls[0].(*goast.GenDecl).Specs[0].(*goast.TypeSpec)
+	out, err := printNode(fset, spec.Type, f.Comments, 1)
+	if err != nil {
+		return typ
+	}
+	return out
+}
+
+func formatMethod(m *MethodDecl) (string, error) {
+	fset := token.NewFileSet()
+	placeholder, ok := parentPlaceholder(m.Body)
+	if !ok {
+		return "", posErrorf(m.Position, "method %s: cannot format the body", m.Name)
+	}
+	body := strings.Replace(m.Body, "parent::", placeholder, -1)
+	src := "package p\nfunc _" + m.Params + " " + body
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", posErrorf(m.Position, "method %s: %s", m.Name, strings.TrimPrefix(err.Error(), "2:"))
+	}
+	fn := f.Decls[0].(*goast.FuncDecl)
+
+	params, err := printNode(fset, fn.Type, nil, 1)
+	if err != nil {
+		return "", err
+	}
+	out, err := printNode(fset, fn.Body, f.Comments, 1)
+	if err != nil {
+		return "", err
+	}
+	out = strings.Replace(out, placeholder, "parent::", -1)
+
+	var prefix string
+	for _, mod := range m.Modifiers {
+		prefix += mod + " "
+	}
+	return "\t" + prefix + "func " + m.Name + strings.TrimPrefix(params, "func") + " " + out + "\n", nil
+}
+
+// printNode prints a Go syntax node the way gofmt does, indented by the given number of tabs. The first line is not
+// indented, since it continues a line that is already started.
+func printNode(fset *token.FileSet, node goast.Node, comments []*goast.CommentGroup, indent int) (string, error) {
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8, Indent: indent}
+	var n interface{} = node
+	if comments != nil {
+		n = &printer.CommentedNode{Node: node, Comments: comments}
+	}
+	if err := cfg.Fprint(&buf, fset, n); err != nil {
+		return "", err
+	}
+	return strings.TrimLeft(buf.String(), "\t"), nil
+}
@@ -0,0 +1,56 @@
+package translate
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	src := "package test\n\n\n" +
+		"import \"fmt\"\n" +
+		"// A point\n" +
+		"class Point extends gopp.Base equatable {\n" +
+		"  x, y int `json:\"x\"`   = 1, 2 // coordinates\n" +
+		"\tname string @json(\"n\") @readonly\n" +
+		"  sync.Mutex\n" +
+		"\n" +
+		"    // String returns the name\n" +
+		"override func String( ) string {\n" +
+		"return fmt.Sprint(this.name,parent::String())\n" +
+		"    }\n" +
+		"}\n"
+	want := "package test\n\n" +
+		"import \"fmt\"\n\n" +
+		"// A point\n" +
+		"class Point extends gopp.Base equatable {\n" +
+		"\tx, y int    `json:\"x\"` = 1, 2 // coordinates\n" +
+		"\tname string @json(\"n\") @readonly\n" +
+		"\tsync.Mutex\n" +
+		"\n" +
+		"\t// String returns the name\n" +
+		"\toverride func String() string {\n" +
+		"\t\treturn fmt.Sprint(this.name, parent::String())\n" +
+		"\t}\n" +
+		"}\n"
+
+	out, err := Format("point.gpp", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != want {
+		t.Errorf("Wrong format:\n%s", out)
+	}
+	if again, err := Format("point.gpp", out); err != nil || string(again) != string(out) {
+		t.Errorf("Format is not idempotent:\n%s", again)
+	}
+}
+
+func TestFormatParentIdentifier(t *testing.T) {
+	src := "package test\n\nclass A extends B {\n\tfunc F() {\n\t\tparent_ := paren__\n\t\tparent::F(parent_.x)\n\t}\n}\n"
+	out, err := Format("a.gpp", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != src {
+		t.Errorf("Identifiers that look like parent:: changed:\n%s", out)
+	}
+}
@@ -0,0 +1,150 @@
+package translate
+
+import (
+	"strings"
+)
+
+// classes returns the classes declared in the ast, in file order.
+func (a ast) classes() []*classDef {
+	var out []*classDef
+	for _, s := range a {
+		if c, ok := s.(*classDef); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+/**
+Resolve the classes in the ast of a file, which fills in the information about each class that depends on its
+superclasses. All the files of a package should be parsed before any of them are resolved, so that superclasses can
+be declared in any order and in any file of the package. Classes are resolved in inheritance order, so that a
+superclass is always resolved before its subclasses, and an error is returned if the classes extend each other in
+a cycle. Superclasses declared in other files are not changed, so different files can be resolved at the same time.
+*/
+func resolveClasses(a ast) error {
+	classes := a.classes()
+	sorted, err := sortClasses(classes)
+	if err != nil {
+		return err
+	}
+	own := make(map[*classDef]bool)
+	for _, c := range classes {
+		own[c] = true
+	}
+	for _, c := range sorted {
+		if own[c] {
+			if err := c.resolve(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortClasses returns the classes sorted so that superclasses that are in the same package come before their
+// subclasses. It returns an error if there is an inheritance cycle.
+func sortClasses(cs []*classDef) ([]*classDef, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*classDef]int)
+	var sorted []*classDef
+
+	var visit func(c *classDef, path []string) error
+	visit = func(c *classDef, path []string) error {
+		path = append(path, c.Name)
+		switch state[c] {
+		case visited:
+			return nil
+		case visiting:
+			return posErrorf(c.decl.Position, "inheritance cycle: %s", strings.Join(path, " extends "))
+		}
+		state[c] = visiting
+		if p := c.parentClass(); p != nil {
+			if err := visit(p, path); err != nil {
+				return err
+			}
+		}
+		state[c] = visited
+		sorted = append(sorted, c)
+		return nil
+	}
+
+	for _, c := range cs {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// parentClass returns the superclass of c, or nil if the superclass is not declared in the package of c. Superclasses
+// that are qualified with a package name are in a different package.
+func (c *classDef) parentClass() *classDef {
+	if c.scope == nil || strings.Contains(c.Extends, ".") {
+		return nil
+	}
+	return c.scope.classes[c.Extends]
+}
+
+// resolve fills in the information about a class that depends on its superclasses and on the configuration of its
+// package.
+func (c *classDef) resolve() error {
+	var naming Naming
+	if c.scope != nil {
+		naming = c.scope.config.Naming
+	}
+	if err := c.applyNaming(naming); err != nil {
+		return err
+	}
+
+	// A class without a Construct function uses the Construct function of the closest superclass that has one
+	c.NewParams = ""
+	for p := c; p != nil; p = p.parentClass() {
+		if p.HasConstructor {
+			c.NewParams = p.ConstructorParams
+			break
+		}
+	}
+
+	// pull apart params to find variable names
+	var vars []string
+	for _, sVarDec := range strings.Split(c.NewParams, ",") {
+		fields := strings.Fields(sVarDec)
+		if len(fields) > 0 {
+			vars = append(vars, fields[0])
+		}
+	}
+	c.ParentVarList = strings.Join(vars, ",")
+
+	for i, f := range c.Funcs {
+		c.Funcs[i].ProcessedBody = c.processFuncBody(f)
+	}
+
+	if c.Equatable {
+		c.EqualType, c.EqualChain = c.equalType(naming)
+	}
+	return nil
+}
+
+// equalType returns the name of the interface that the Equal function of an equatable class takes, which is the
+// interface of the topmost class in its hierarchy that is marked equatable, and whether a superclass is equatable.
+func (c *classDef) equalType(naming Naming) (string, bool) {
+	if c.inheritedEqual != "" {
+		return c.inheritedEqual, true
+	}
+	eqType := c.Interface
+	chain := false
+	for p := c.parentClass(); p != nil; p = p.parentClass() {
+		if p.decl.HasModifier(modEquatable) {
+			// The superclass is in the same package, so it has the same naming
+			names, _ := naming.names(p.Name)
+			eqType = names.Interface
+			chain = true
+		}
+	}
+	return eqType, chain
+}
@@ -0,0 +1,178 @@
+package translate
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runOptions are the options used to generate the packages in testdata/run, by the name of their directory, which also
+// apply to the packages in its subdirectories. The other packages are generated with the default options.
+var runOptions = map[string]Options{
+	"json":   {JSON: true},
+	"naming": {JSON: true, Naming: Naming{Interface: "{Name}", Struct: "{name}", Receiver: "{n}"}},
+}
+
+// TestRun generates the code of the packages in testdata/run, and runs their tests with go test, so that the generated
+// code is tested by what it does rather than by how it looks. The packages are built as a module that uses the gopp
+// package of this repository.
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the generated packages are not built in short mode")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is not available")
+	}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "run")
+	if err := os.CopyFS(dir, os.DirFS(filepath.Join("testdata", "run"))); err != nil {
+		t.Fatal(err)
+	}
+	gopp := filepath.Join(root, "gopp")
+	if err := os.Mkdir(gopp, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"base.go", "equal.go"} {
+		src, err := os.ReadFile(filepath.Join("..", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(gopp, name), string(src))
+	}
+	writeFile(t, filepath.Join(gopp, "go.mod"), "module "+goppImportPath+"\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(dir, "go.mod"), "module run\n\ngo 1.21\n\nrequire "+goppImportPath+
+		" v0.0.0\n\nreplace "+goppImportPath+" => ../gopp\n")
+
+	// The module is also used to find the packages of superclasses while generating
+	for _, env := range [][2]string{{"GO111MODULE", "on"}, {"GOFLAGS", "-mod=mod"}, {"GOPROXY", "off"}, {"GOWORK", "off"}} {
+		t.Setenv(env[0], env[1])
+	}
+	pkgs, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range pkgs {
+		if pkg.IsDir() {
+			generateDir(t, filepath.Join(dir, pkg.Name()), runOptions[pkg.Name()])
+		}
+	}
+
+	// go test only runs some of the checks of go vet, so the others, like copylocks, are run too. The structtag check is
+	// left out, since it reports the json tags of members that are not exported, which gopp's JSON functions use.
+	for _, args := range [][]string{{"test", "./..."}, {"vet", "-structtag=false", "./..."}} {
+		cmd := exec.Command(goCmd, args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("go %s failed: %v\n%s", args[0], err, out)
+		}
+	}
+}
+
+// generateDir generates the code of the .gpp files in a directory and its subdirectories with one session, and writes
+// it beside them.
+func generateDir(t *testing.T, dir string, opts Options) {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".gpp") {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSession(opts)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Add(file, src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range files {
+		r, err := s.Generate(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, OutputName(file), string(r.Source))
+	}
+}
+
+func writeFile(t *testing.T, name string, data string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestEqualFromGoCode tests that an equatable class takes the interface of the Equal function that it inherits from a
+// superclass whose package is only found as Go code, since it is not in the session.
+func TestEqualFromGoCode(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("the go command is not available")
+	}
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module ext\n\ngo 1.21\n")
+	for _, sub := range []string{"geo", "app"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(dir, "geo", "point.go"), `package geo
+
+type ShapeI interface {
+	Equal(other ShapeI) bool
+}
+
+type Shape struct{}
+
+func (s *Shape) Equal(other ShapeI) bool { return true }
+
+type Point struct {
+	Shape
+}
+`)
+	for _, env := range [][2]string{{"GO111MODULE", "on"}, {"GOFLAGS", "-mod=mod"}, {"GOPROXY", "off"}, {"GOWORK", "off"}} {
+		t.Setenv(env[0], env[1])
+	}
+	// Like gopp check, the packages are found in the module of the current directory
+	t.Chdir(dir)
+
+	src := `package app
+
+import g "ext/geo"
+
+class Place extends g.Point equatable {
+	name string
+}
+`
+	file := filepath.Join(dir, "app", "place.gpp")
+	s := NewSession(Options{})
+	if err := s.Add(file, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Generate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(r.Source), "Equal(gopp_other g.ShapeI) bool {\n\tif !p_.Point.Equal(gopp_other) {") {
+		t.Errorf("Equal does not take the interface of the inherited Equal:\n%s", r.Source)
+	}
+
+	src = strings.Replace(src, `import g "ext/geo"`, `import "ext/geo"`, 1)
+	src = strings.Replace(src, "g.Point", "geo.Point", 1)
+	if err := s.Add(file, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Generate(file); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,359 @@
+package translate
+
+import (
+	"fmt"
+	"go/format"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/template"
+)
+
+// Session holds the state of translating a group of .gpp files, like all of the files of a package, or all of the
+// files given to one run of the gopp command. Classes are kept in a separate scope for each package, so that a class
+// can only extend classes declared in its own package, or in other packages by qualifying the name with the package.
+// A Session is not safe for concurrent use, except that Generate and Hash can be called for several files at the same
+// time while no files are being added or removed.
+type Session struct {
+	opts     Options
+	packages map[string]*pkgScope
+	scopes   []*pkgScope // the packages in the order they were found
+	files    map[string]ast
+	srcs     map[string][]byte // the source of each file
+	configs  map[string]Config // the configuration of the files in each directory, set by SetConfig
+
+	generators []generatorEntry // the generators added by AddGenerator
+
+	importMu sync.Mutex
+	importer types.ImporterFrom // loads the Go code of packages that have superclasses of classes
+}
+
+// pkgScope holds the classes declared in the files of one package.
+type pkgScope struct {
+	name    string
+	dir     string
+	config  Config             // the configuration of the package
+	tmpl    *template.Template // the templates of the configuration
+	tmplErr error              // the error in the templates of the configuration
+	classes map[string]*classDef
+	files   []string // the files of the package, in the order they were added
+}
+
+// NewSession returns a new Session that translates files using the given options.
+func NewSession(opts Options) *Session {
+	return &Session{
+		opts:     opts,
+		packages: make(map[string]*pkgScope),
+		configs:  make(map[string]Config),
+		files:    make(map[string]ast),
+		srcs:     make(map[string][]byte),
+	}
+}
+
+// Add parses the .gpp file with the given name and source, and adds its classes to the session. All of the files of
+// a package should be added before any of them are generated, so that classes can extend classes declared in any of
+// the files. A file that was already added is replaced, unless the new source has errors, in which case the previous
+// version is kept. The returned error is a Diagnostics.
+func (s *Session) Add(filename string, src []byte) error {
+	tree, err := s.parseString(filename, string(src))
+	if err != nil {
+		return Diagnostics{diagnostic(filename, err)}
+	}
+	s.files[filename] = tree
+	s.srcs[filename] = src
+	return nil
+}
+
+// Source is the name and content of a .gpp file.
+type Source struct {
+	Name string
+	Src  []byte
+}
+
+// AddAll adds several files to the session, parsing up to jobs of them at the same time. The files are added to the
+// session in the order they are given, so the result does not depend on the number of jobs. It returns an error for
+// each file, which is nil if the file was added, or a Diagnostics.
+func (s *Session) AddAll(files []Source, jobs int) []error {
+	type parsed struct {
+		f    *File
+		tree ast
+		err  error
+	}
+	results := make([]parsed, len(files))
+	parallel(len(files), jobs, func(i int) {
+		f, tree, err := parseTree(files[i].Name, string(files[i].Src))
+		results[i] = parsed{f, tree, err}
+	})
+
+	errs := make([]error, len(files))
+	for i, file := range files {
+		tree, err := results[i].tree, results[i].err
+		if err == nil {
+			tree, err = s.register(file.Name, results[i].f, tree)
+		}
+		if err != nil {
+			errs[i] = Diagnostics{diagnostic(file.Name, err)}
+			continue
+		}
+		s.files[file.Name] = tree
+		s.srcs[file.Name] = file.Src
+	}
+	return errs
+}
+
+// GenerateAll generates several files, up to jobs of them at the same time, and returns the results and errors of
+// Generate in the same order as the files. The generated code does not depend on the number of jobs.
+func (s *Session) GenerateAll(files []string, jobs int) ([]*Result, []error) {
+	results := make([]*Result, len(files))
+	errs := make([]error, len(files))
+	parallel(len(files), jobs, func(i int) {
+		results[i], errs[i] = s.Generate(files[i])
+	})
+	return results, errs
+}
+
+// parallel calls fn for each index from 0 to n-1, running up to jobs calls at the same time, and returns when all of
+// them are done.
+func parallel(n int, jobs int, fn func(i int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for j := 0; j < jobs && j < n; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// SetConfig sets the configuration of the files in dir, which is the directory part of their file names, instead of
+// the naming and templates of the options of the session. It is usually the configuration returned by LoadConfig.
+func (s *Session) SetConfig(dir string, c Config) {
+	dir = filepath.Clean(dir)
+	s.configs[dir] = c
+	for _, scope := range s.scopes {
+		if scope.dir == dir {
+			scope.setConfig(c)
+		}
+	}
+}
+
+// setConfig sets the configuration of the package and parses its templates. Errors in the templates are reported when
+// the code of a class in the package is generated.
+func (scope *pkgScope) setConfig(c Config) {
+	scope.config = c
+	scope.tmpl, scope.tmplErr = parseTemplates(c.Templates)
+}
+
+// Remove removes a file and its classes from the session.
+func (s *Session) Remove(filename string) {
+	s.remove(filename)
+	delete(s.files, filename)
+	delete(s.srcs, filename)
+}
+
+func (s *Session) remove(filename string) {
+	for _, scope := range s.scopes {
+		for name, c := range scope.classes {
+			if c.file == filename {
+				delete(scope.classes, name)
+			}
+		}
+		for i, f := range scope.files {
+			if f == filename {
+				scope.files = append(scope.files[:i], scope.files[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Dependents returns the files of the session that declare a class that extends a class declared in the named file,
+// either directly or through other classes. These files need to be generated again when the file changes.
+func (s *Session) Dependents(filename string) []string {
+	var deps []string
+	for _, scope := range s.scopes {
+	files:
+		for _, f := range scope.files {
+			if f == filename {
+				continue
+			}
+			for _, c := range s.files[f].classes() {
+				seen := make(map[*classDef]bool)
+				for p := c.parentClass(); p != nil && !seen[p]; p = p.parentClass() {
+					if p.file == filename {
+						deps = append(deps, f)
+						continue files
+					}
+					seen[p] = true
+				}
+			}
+		}
+	}
+	return deps
+}
+
+// Generate generates the Go code of a file that was added to the session, formatted by go/format. If the generated
+// code is not valid Go syntax, which can happen when a method body has a syntax error, the errors are reported as
+// Diagnostics at the lines of the .gpp file they come from, and no code is returned. The header of the code records
+// the Hash of the file. The returned error is a Diagnostics that holds the same problems as the Diagnostics of the
+// result.
+func (s *Session) Generate(filename string) (r *Result, err error) {
+	tree, ok := s.files[filename]
+	if !ok {
+		return nil, fmt.Errorf("file %s was not added to the session", filename)
+	}
+	r = &Result{}
+	defer func() {
+		if p := recover(); p != nil {
+			r.Source = nil
+			r.Diagnostics = append(r.Diagnostics, Diagnostic{File: filename, Message: fmt.Sprint(p)})
+			err = r.Diagnostics
+		}
+	}()
+
+	out, lines, err := s.generate(tree)
+	for _, c := range tree.classes() {
+		r.Classes = append(r.Classes, c.info())
+	}
+	if err != nil {
+		r.Diagnostics = append(r.Diagnostics, diagnostic(filename, err))
+		return r, r.Diagnostics
+	}
+	src, err := format.Source([]byte(generatedHeader + out))
+	if err != nil {
+		r.Diagnostics = append(r.Diagnostics, codeDiagnostics(filename, err, lines)...)
+		return r, r.Diagnostics
+	}
+
+	codes := s.runGenerators(filename, tree, r)
+	if r.Diagnostics != nil {
+		return r, r.Diagnostics
+	}
+	hash := s.Hash(filename)
+	var suffixes []string
+	for suffix := range codes {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Strings(suffixes)
+	for _, suffix := range suffixes {
+		code := codes[suffix]
+		if suffix == "" {
+			src, err = appendCode(src, code)
+		} else {
+			var file []byte
+			if file, err = generatorFile(r.Classes[0].Package, code); err == nil {
+				if r.Files == nil {
+					r.Files = make(map[string][]byte)
+				}
+				r.Files[suffix] = addHash(file, hash)
+			}
+		}
+		if err != nil {
+			r.Diagnostics = append(r.Diagnostics, Diagnostic{File: filename,
+				Message: fmt.Sprintf("generated code of %s: %v", SuffixName(OutputName(filepath.Base(filename)), suffix), err)})
+		}
+	}
+	if r.Diagnostics != nil {
+		r.Files = nil
+		return r, r.Diagnostics
+	}
+	r.Source = addHash(src, hash)
+	return r, nil
+}
+
+// parseString parses gopp formatted code that comes from the named file, and adds its classes to the scope of its
+// package. The package is identified by the directory of the file and the package name, so files in different
+// directories are always in different packages.
+func (s *Session) parseString(file string, input string) (ast, error) {
+	f, tree, err := parseTree(file, input)
+	if err != nil {
+		return nil, err
+	}
+	return s.register(file, f, tree)
+}
+
+// parseTree parses a file into the ast used for code generation. It does not use the session, so several files can
+// be parsed at the same time.
+func parseTree(file string, input string) (*File, ast, error) {
+	f, err := parseFile(file, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	tree, err := newAST(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, tree, nil
+}
+
+// register adds the classes of a parsed file to the scope of its package.
+func (s *Session) register(file string, f *File, tree ast) (ast, error) {
+	dir := ""
+	if file != "" {
+		dir = filepath.Dir(file)
+	}
+	scope := s.scope(dir, f.Package)
+
+	// Classes are added after the whole file is parsed, so that a subclass can come before its superclass in the file
+	declared := make(map[string]bool)
+	for _, c := range tree.classes() {
+		if declared[c.Name] {
+			return nil, posErrorf(c.decl.Position, "class %s is declared more than once", c.Name)
+		}
+		if prev, ok := scope.classes[c.Name]; ok && prev.file != file {
+			return nil, posErrorf(c.decl.Position, "class %s is already declared in %s", c.Name, prev.file)
+		}
+		declared[c.Name] = true
+	}
+	s.remove(file)
+	for _, c := range tree.classes() {
+		c.file = file
+		c.scope = scope
+		c.JSON = s.opts.JSON
+		scope.classes[c.Name] = c
+	}
+	scope.files = append(scope.files, file)
+	return tree, nil
+}
+
+// scope returns the scope of the package with the given directory and name, creating it if needed.
+func (s *Session) scope(dir string, name string) *pkgScope {
+	key := dir + ":" + name
+	scope, ok := s.packages[key]
+	if !ok {
+		config, ok := s.configs[dir]
+		if !ok {
+			config = Config{Naming: s.opts.Naming, Templates: s.opts.Templates}
+		}
+		scope = &pkgScope{name: name, dir: dir, classes: make(map[string]*classDef)}
+		scope.setConfig(config)
+		s.packages[key] = scope
+		s.scopes = append(s.scopes, scope)
+	}
+	return scope
+}
+
+// generate resolves the classes of a parsed file and returns the go code for the file, which is not formatted, along
+// with the map of its lines to the lines of the file. All of the files of the package should be parsed first.
+func (s *Session) generate(tree ast) (string, lineMap, error) {
+	if err := s.inheritEqual(tree); err != nil {
+		return "", nil, err
+	}
+	if err := resolveClasses(tree); err != nil {
+		return "", nil, err
+	}
+	out, lines := tree.generate()
+	return out, lines, nil
+}
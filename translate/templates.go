@@ -0,0 +1,341 @@
+package translate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// templateNames are the names of the templates that generate the code of a class. The "class" template generates the
+// whole class by calling the others, and each of them can be replaced by a template of the same name.
+var templateNames = []string{
+	"class",       // the whole class
+	"interface",   // the interface of the class
+	"struct",      // the struct of the class
+	"constructor", // the function that creates an object of the class
+	"method",      // one method, which is given a method of the class
+	"reflection",  // the IsA, Class, Is and As functions
+	"defaults",    // the InitDefaults function, if a member has a default value
+	"clone",       // the Clone and CloneMembers functions
+	"equal",       // the Equal and Hash functions of an equatable class
+	"json",        // the JSON functions, if they are enabled
+}
+
+// templateFuncs are the helper functions that templates can use, along with the functions that text/template
+// defines.
+var templateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"lowerFirst": lowerFirst,
+	"upperFirst": upperFirst,
+	"join":       strings.Join,
+	"split":      strings.Split,
+	"replace":    strings.ReplaceAll,
+	"trimSpace":  strings.TrimSpace,
+	"hasPrefix":  strings.HasPrefix,
+	"hasSuffix":  strings.HasSuffix,
+	"quote":      strconv.Quote,
+}
+
+// defaultTemplate holds the templates that generate the code of a class when none are replaced.
+var defaultTemplate = newDefaultTemplate()
+
+// newDefaultTemplate parses the built in templates. Each of them is also named with a "default." prefix, like
+// default.method, so that a template that replaces it can still call it.
+func newDefaultTemplate() *template.Template {
+	tmpl := template.Must(template.New("gopp").Funcs(templateFuncs).Parse(classTemplates))
+	for _, name := range templateNames {
+		template.Must(tmpl.AddParseTree("default."+name, tmpl.Lookup(name).Tree))
+	}
+	return tmpl
+}
+
+// parseTemplates returns the templates that generate the code of a class, with the named templates replaced by the
+// given sources.
+func parseTemplates(overrides map[string]string) (*template.Template, error) {
+	if len(overrides) == 0 {
+		return defaultTemplate, nil
+	}
+	tmpl := template.Must(defaultTemplate.Clone())
+	// The templates are parsed in order, so that errors do not depend on the order of the map
+	var names []string
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !isTemplateName(name) {
+			return nil, fmt.Errorf("unknown template %s", name)
+		}
+		if _, err := tmpl.New(name).Parse(overrides[name]); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+func isTemplateName(name string) bool {
+	for _, n := range templateNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTemplates reads the templates in dir that replace the templates that generate the code of a class. Each file
+// is named after the template it replaces, with a .tmpl extension, like method.tmpl. The returned map holds the source
+// of each template by name. The returned error is a Diagnostics if a template has errors.
+func LoadTemplates(dir string) (map[string]string, error) {
+	if _, err := ioutil.ReadDir(dir); err != nil {
+		return nil, err
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	templates := make(map[string]string)
+	var diags Diagnostics
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		if _, err := parseTemplates(map[string]string{name: string(src)}); err != nil {
+			diags = append(diags, Diagnostic{File: file, Message: err.Error()})
+			continue
+		}
+		templates[name] = string(src)
+	}
+	if diags != nil {
+		return nil, diags
+	}
+	return templates, nil
+}
+
+// classMethod is a method of a class, which is given to the "method" template.
+type classMethod struct {
+	funcDef
+	Class *classDef // the class of the method
+}
+
+// Methods returns the methods of the class, including Construct, for the "method" template.
+func (c *classDef) Methods() []classMethod {
+	var methods []classMethod
+	for _, f := range c.Funcs {
+		methods = append(methods, classMethod{f, c})
+	}
+	return methods
+}
+
+// Modifiers returns the modifiers of the class, like equatable.
+func (c *classDef) Modifiers() []string {
+	return c.decl.Modifiers
+}
+
+// Parents returns the superclasses of the class that are declared in its package, starting with its superclass.
+func (c *classDef) Parents() []*classDef {
+	var parents []*classDef
+	seen := map[*classDef]bool{c: true}
+	for p := c.parentClass(); p != nil && !seen[p]; p = p.parentClass() {
+		seen[p] = true
+		parents = append(parents, p)
+	}
+	return parents
+}
+
+// Decl returns the declaration of the class in the .gpp file, which holds its comments, annotations and positions.
+func (c *classDef) Decl() *ClassDecl {
+	return c.decl
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// classTemplates are the templates that generate the code of a class.
+const classTemplates = `{{define "class"}}
+{{.Comment}}
+{{template "interface" .}}
+{{template "struct" .}}
+{{template "constructor" .}}
+
+{{range .Methods}}{{template "method" .}}{{end}}
+{{- template "reflection" .}}
+{{- if .HasDefaults}}{{template "defaults" .}}{{end}}
+{{- template "clone" .}}
+{{- if .Equatable}}{{template "equal" .}}{{end}}
+{{- if .JSON}}{{template "json" .}}{{end}}
+{{- end}}
+{{define "interface"}}type {{.Interface}} interface {
+	{{.ParentInterface}}
+{{range .Funcs}} {{if and (not (eq .Name "Construct")) (not .IsOverride)}}
+	{{.Name}}{{.Params}}{{end}}{{end}}
+{{- if and .Equatable (not .EqualChain)}}
+	Equal(other {{.EqualType}}) bool
+	Hash() uint64
+{{- end}}
+}
+{{end}}
+{{define "struct"}}type {{.Struct}} struct {
+	{{.ParentStruct}}
+{{range .Members}}{{if .Comment}}	{{.Comment}}
+{{end}}	{{.StructField}}
+{{end}}}
+{{end}}
+{{define "constructor"}}// New {{.Name}} creates a new {{.Name}} object and returns its matching interface
+func {{.Constructor}} ({{.NewParams}}) {{.Interface}} {
+	gopp_obj := {{.Struct}}{}
+	gopp_obj.Init(&gopp_obj)
+	gopp_obj.InitDefaults()
+	gopp_obj.Construct({{.ParentVarList}})
+	return gopp_obj.I().({{.Interface}})
+}
+{{end}}
+{{define "method"}}
+func ({{.Class.Receiver}} *{{.Class.Struct}}) {{.Name}} {{.Params}} {
+{{.ProcessedBody}}
+}
+{{end}}
+{{define "reflection"}}
+func ({{$.Receiver}} *{{$.Struct}}) IsA(gopp_className string) bool {
+	if gopp_className == "{{$.Name}}" {
+		return true
+	}
+	return {{$.Receiver}}.{{$.Parent}}.IsA(gopp_className)
+}
+
+func ({{$.Receiver}} *{{$.Struct}}) Class() string {
+	return "{{$.Name}}"
+}
+
+func ({{$.Receiver}} *{{$.Struct}}) as{{$.Name}}_() *{{$.Struct}} {
+	return {{$.Receiver}}
+}
+
+// Is{{.Name}} returns true if obj is a {{.Name}} or a subclass of {{.Name}}.
+func Is{{.Name}}(obj gopp.BaseI) bool {
+	_, ok := As{{.Name}}(obj)
+	return ok
+}
+
+// As{{.Name}} returns obj as a {{.Interface}} if it is a {{.Name}} or a subclass of {{.Name}}.
+func As{{.Name}}(obj gopp.BaseI) ({{.Interface}}, bool) {
+	if _, ok := gopp.As[interface{ as{{.Name}}_() *{{.Struct}} }](obj); !ok {
+		return nil, false
+	}
+	return gopp.As[{{.Interface}}](obj)
+}
+{{end}}
+{{define "defaults"}}
+// InitDefaults sets the members of {{.Name}} and its superclasses to their default values. It is called before Construct.
+func ({{$.Receiver}} *{{$.Struct}}) InitDefaults() {
+	{{$.Receiver}}.{{$.Parent}}.InitDefaults()
+{{- range .Members}}{{if .Default}}
+	{{$.ConvertDefault .}}
+{{- end}}{{end}}
+}
+{{end}}
+{{define "clone"}}
+// Clone returns a copy of the object that is initialized so that virtual calls on it go to the copy.
+func ({{$.Receiver}} *{{$.Struct}}) Clone() gopp.BaseI {
+	gopp_c := new({{$.Struct}})
+	gopp_c.Init(gopp_c)
+	{{$.Receiver}}.CloneMembers(gopp_c)
+	return gopp_c.I()
+}
+
+// CloneMembers copies the members of {{.Name}} and its superclasses into gopp_c. Slices and maps are copied, other members are assigned.
+func ({{$.Receiver}} *{{$.Struct}}) CloneMembers(gopp_c *{{$.Struct}}) {
+	{{$.Receiver}}.{{$.Parent}}.CloneMembers(&gopp_c.{{$.Parent}})
+{{- range .ValueFields}}
+	gopp_c.{{.Name}} = {{.CloneExpr $.Receiver}}
+{{- end}}
+}
+{{end}}
+{{define "equal"}}
+// Equal returns true if gopp_other is the same class as the object and its members are equal to the members of the object.
+func ({{$.Receiver}} *{{$.Struct}}) Equal(gopp_other {{.EqualType}}) bool {
+{{- if .EqualChain}}
+	if !{{$.Receiver}}.{{$.Parent}}.Equal(gopp_other) {
+		return false
+	}
+{{- else}}
+	if gopp_other == nil || gopp_other.I().Class() != {{$.Receiver}}.I().Class() {
+		return false
+	}
+{{- end}}
+	{{if .ValueFields}}gopp_o{{else}}_{{end}}, gopp_ok := gopp_other.I().(interface{ as{{$.Name}}_() *{{$.Struct}} })
+	return gopp_ok{{range .ValueFields}} &&
+		gopp.Equal({{$.Receiver}}.{{.Name}}, gopp_o.as{{$.Name}}_().{{.Name}}){{end}}
+}
+
+// Hash returns a hash of the class and members of the object. Objects that are Equal have the same hash.
+func ({{$.Receiver}} *{{$.Struct}}) Hash() uint64 {
+{{- if .EqualChain}}
+	return gopp.Hash({{$.Receiver}}.{{$.Parent}}.Hash(){{range .ValueFields}}, {{$.Receiver}}.{{.Name}}{{end}})
+{{- else}}
+	return gopp.Hash({{$.Receiver}}.I().Class(){{range .ValueFields}}, {{$.Receiver}}.{{.Name}}{{end}})
+{{- end}}
+}
+{{end}}
+{{define "json"}}
+func init() {
+	gopp.RegisterClass(gopp.ClassName(new({{.Struct}})), func() gopp.BaseI {
+		gopp_obj := new({{.Struct}})
+		gopp_obj.Init(gopp_obj)
+		gopp_obj.InitDefaults()
+{{- if not .NewParams}}
+		gopp_obj.Construct()
+{{- end}}
+		return gopp_obj.I()
+	})
+}
+
+// MarshalFields adds the members of {{.Name}} and its superclasses to gopp_m.
+func ({{$.Receiver}} *{{$.Struct}}) MarshalFields(gopp_m map[string]interface{}) {
+	{{$.Receiver}}.{{$.Parent}}.MarshalFields(gopp_m)
+{{- range .Fields}}{{if .JSONName}}
+	gopp_m["{{.JSONName}}"] = {{$.Receiver}}.{{.Name}}
+{{- end}}{{end}}
+}
+
+// UnmarshalFields decodes the members of {{.Name}} and its superclasses from gopp_f.
+func ({{$.Receiver}} *{{$.Struct}}) UnmarshalFields(gopp_f gopp.Fields) error {
+	if gopp_err := {{$.Receiver}}.{{$.Parent}}.UnmarshalFields(gopp_f); gopp_err != nil {
+		return gopp_err
+	}
+{{- range .Fields}}{{if and .JSONName (not .ReadOnly)}}
+
+	if gopp_err := gopp_f.{{if .Required}}DecodeRequired{{else}}Decode{{end}}("{{.JSONName}}", &{{$.Receiver}}.{{.Name}}); gopp_err != nil {
+		return gopp_err
+	}
+{{- end}}{{end}}
+	return nil
+}
+
+// MarshalJSON encodes the object as JSON, including the members of its superclasses and its class name.
+func ({{$.Receiver}} *{{$.Struct}}) MarshalJSON() ([]byte, error) {
+	return gopp.MarshalObject({{$.Receiver}})
+}
+
+// UnmarshalJSON decodes JSON created by MarshalJSON into the object.
+func ({{$.Receiver}} *{{$.Struct}}) UnmarshalJSON(gopp_data []byte) error {
+	if {{$.Receiver}}.I() == nil {
+		{{$.Receiver}}.Init({{$.Receiver}})
+		{{$.Receiver}}.InitDefaults()
+	}
+	return gopp.UnmarshalObject({{$.Receiver}}, gopp_data)
+}
+{{end}}
+`
@@ -0,0 +1,57 @@
+package translate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	src := "package test\n\nclass A extends gopp.Base {\n\tx int @json(\"x\")\n\n\tfunc Get() int {\n\t\treturn this.x\n\t}\n}\n\nclass B extends A {\n}\n"
+	opts := Options{Templates: map[string]string{
+		"method":     "\n// {{.Name}} is a method of {{lowerFirst .Class.Name}}.{{template \"default.method\" .}}",
+		"reflection": "{{template \"default.reflection\" .}}\n// Parents of {{.Name}}:{{range .Parents}} {{.Name}}{{end}}\n",
+	}}
+	r, err := Translate("a.gpp", []byte(src), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Get is a method of a.\nfunc (a_ *A) Get() int {\n\treturn a_.x\n}",
+		"// Parents of B: A\n",
+		"func (b_ *B) IsA(gopp_className string) bool {",
+	} {
+		if !strings.Contains(string(r.Source), want) {
+			t.Errorf("Missing %q in:\n%s", want, r.Source)
+		}
+	}
+	if d, _ := Translate("a.gpp", []byte(src), Options{}); GeneratedHash(d.Source) == GeneratedHash(r.Source) {
+		t.Error("Templates are not part of the hash")
+	}
+
+	if _, err := Translate("a.gpp", []byte(src), Options{Templates: map[string]string{"methods": ""}}); err == nil {
+		t.Error("Unknown template not reported")
+	}
+	if _, err := Translate("a.gpp", []byte(src), Options{Templates: map[string]string{"method": "{{.Missing}}"}}); err == nil {
+		t.Error("Template error not reported")
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "struct.tmpl"), []byte("type {{.Struct}} struct{}\n"), 0666)
+	ioutil.WriteFile(filepath.Join(dir, ConfigName), []byte("[templates]\ndir = \".\"\n"), 0666)
+	config, _, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Templates) != 1 || config.Templates["struct"] == "" {
+		t.Errorf("Templates not loaded: %v", config.Templates)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "json.tmpl"), []byte("{{if}}"), 0666)
+	if _, err := LoadTemplates(dir); err == nil || !strings.Contains(err.Error(), "json.tmpl") {
+		t.Errorf("Template error not reported: %v", err)
+	}
+}
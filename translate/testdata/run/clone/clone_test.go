@@ -0,0 +1,24 @@
+package clone
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	s := NewSquare(2)
+	c, ok := AsSquare(s.Clone())
+	if !ok {
+		t.Fatal("The clone of a Square is not a Square")
+	}
+	c.Resize(3)
+	c.(*Square).tags[0] = "round"
+	c.(*Square).props["corners"] = 0
+	orig := s.(*Square)
+	if orig.side != 2 || orig.tags[0] != "flat" || orig.props["corners"] != 4 {
+		t.Errorf("Changing the clone changed the original: %+v", orig)
+	}
+	if c.(*Square).side != 3 || c.(*Square).name != "square" {
+		t.Errorf("Members not copied: %+v", c)
+	}
+	if c.I() != c.(*Square) || c.Name() != "square" {
+		t.Error("The clone does not make virtual calls on itself")
+	}
+}
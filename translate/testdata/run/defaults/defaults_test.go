@@ -0,0 +1,14 @@
+package defaults
+
+import "testing"
+
+func TestDefaults(t *testing.T) {
+	c := NewCounter().(*Counter)
+	if c.count != 11 || cap(c.items) != 8 || c.a != "a" || c.b != "b" {
+		t.Errorf("Defaults not set before Construct: %+v", c)
+	}
+	s := NewSub().(*Sub)
+	if s.count != 11 || s.label != "sub:a" {
+		t.Errorf("Defaults of the superclass not set: %+v", s)
+	}
+}
@@ -0,0 +1,108 @@
+package equal
+
+import (
+	"testing"
+
+	"github.com/spekary/gopp"
+	"run/equal/geo"
+)
+
+func TestEqual(t *testing.T) {
+	a, b, c := NewPoint(1, 2), NewPoint(1, 2), NewPoint(2, 1)
+	if !a.Equal(b) || a.Equal(c) || a.Hash() != b.Hash() {
+		t.Error("Wrong equality of points")
+	}
+	p, q := NewPoint3D(1, 2, 3), NewPoint3D(1, 2, 3)
+	if !p.Equal(q) || p.Hash() != q.Hash() || p.Equal(NewPoint3D(1, 2, 4)) || p.Equal(NewPoint3D(0, 2, 3)) {
+		t.Error("Wrong equality of 3D points")
+	}
+	if a.Equal(p) || p.Equal(a) {
+		t.Error("Objects of different classes are equal")
+	}
+
+	// Labeled is not marked equatable, but inherits it from Point
+	l := NewLabeled(1, 2, "a")
+	if !l.Equal(NewLabeled(1, 2, "a")) || l.Hash() != NewLabeled(1, 2, "a").Hash() || l.Equal(NewLabeled(1, 2, "b")) ||
+		l.Equal(NewLabeled(2, 2, "a")) || l.Equal(a) || a.Equal(l) {
+		t.Error("Wrong equality of labeled points")
+	}
+
+	if o := NewOrigin(0, 0); !o.Equal(NewOrigin(0, 0)) || o.Equal(NewPoint(0, 0)) || NewPoint(0, 0).Equal(o) {
+		t.Error("Wrong equality of origins")
+	}
+
+	m := map[uint64]PointI{a.Hash(): a}
+	if m[b.Hash()] == nil {
+		t.Error("Equal objects have different hashes")
+	}
+}
+
+// ring returns a ring of n nodes that all have the value v.
+func ring(n int, v int) *node {
+	first := &node{value: v}
+	last := first
+	for i := 1; i < n; i++ {
+		last.next = &node{value: v}
+		last = last.next
+	}
+	last.next = first
+	return first
+}
+
+func TestEqualHash(t *testing.T) {
+	one, other := 1, 1
+	tests := []struct {
+		name  string
+		a, b  *Path
+		equal bool
+	}{
+		{"empty", &Path{}, &Path{}, true},
+		{"points", &Path{points: []PointI{NewPoint(1, 2)}}, &Path{points: []PointI{NewPoint(1, 2)}}, true},
+		{"different points", &Path{points: []PointI{NewPoint(1, 2)}}, &Path{points: []PointI{NewPoint(2, 2)}}, false},
+		{"subclass points", &Path{points: []PointI{NewPoint(1, 2)}}, &Path{points: []PointI{NewLabeled(1, 2, "a")}}, false},
+		{"labeled points", &Path{points: []PointI{NewLabeled(1, 2, "a")}}, &Path{points: []PointI{NewLabeled(1, 2, "a")}}, true},
+		// Labeled is equatable because Point is, so its own members are compared too
+		{"different labels", &Path{points: []PointI{NewLabeled(1, 2, "a")}}, &Path{points: []PointI{NewLabeled(1, 2, "b")}}, false},
+		{"shared pointers", &Path{start: &one, end: &one}, &Path{start: &one, end: &other}, true},
+		{"cycles", &Path{loop: ring(1, 5)}, &Path{loop: ring(1, 5)}, true},
+		{"different cycles", &Path{loop: ring(1, 5)}, &Path{loop: ring(2, 5)}, false},
+	}
+	for _, test := range tests {
+		a, b := NewPath().(*Path), NewPath().(*Path)
+		a.points, a.start, a.end, a.loop = test.a.points, test.a.start, test.a.end, test.a.loop
+		b.points, b.start, b.end, b.loop = test.b.points, test.b.start, test.b.end, test.b.loop
+		if a.Equal(b) != test.equal || b.Equal(a) != test.equal {
+			t.Errorf("%s: Equal is not %v", test.name, test.equal)
+		}
+		if a.Equal(b) && a.Hash() != b.Hash() {
+			t.Errorf("%s: Equal objects have different hashes", test.name)
+		}
+		if gopp.Equal(a, b) != a.Equal(b) || gopp.Equal(a, b) && gopp.Hash(a) != gopp.Hash(b) {
+			t.Errorf("%s: gopp.Equal and gopp.Hash do not agree with the object", test.name)
+		}
+	}
+
+	if !gopp.Equal(map[string]PointI{"a": NewPoint(1, 2)}, map[string]PointI{"a": NewPoint(1, 2)}) ||
+		gopp.Hash(map[string]PointI{"a": NewPoint(1, 2)}) != gopp.Hash(map[string]PointI{"a": NewPoint(1, 2)}) {
+		t.Error("Equal maps of points have different hashes")
+	}
+	if gopp.Equal([]int(nil), []int{}) || !gopp.Equal(0.0, -0.0) || gopp.Hash(0.0) != gopp.Hash(-0.0) {
+		t.Error("Wrong equality of basic values")
+	}
+}
+
+func TestEqualOtherPackage(t *testing.T) {
+	a, b := NewPlace(1, 2, "home"), NewPlace(1, 2, "home")
+	if !a.Equal(b) || a.Hash() != b.Hash() || a.Equal(NewPlace(1, 2, "work")) || a.Equal(NewPlace(2, 2, "home")) {
+		t.Error("Wrong equality of places")
+	}
+	if a.Equal(geo.NewPoint(1, 2)) || geo.NewPoint(1, 2).Equal(a) {
+		t.Error("A Place is equal to a Point")
+	}
+
+	// Spot is not marked equatable, but inherits it from geo.Point
+	s := NewSpot(1, 2, "x")
+	if !s.Equal(NewSpot(1, 2, "x")) || s.Hash() != NewSpot(1, 2, "x").Hash() || s.Equal(NewSpot(1, 2, "y")) {
+		t.Error("Wrong equality of spots")
+	}
+}
@@ -0,0 +1,36 @@
+package isa
+
+import (
+	"testing"
+
+	"github.com/spekary/gopp"
+)
+
+func TestIsA(t *testing.T) {
+	var dog AnimalI = NewDog("Rex")
+	if dog.Describe() != "Rex says woof" {
+		t.Errorf("Virtual call not made: %s", dog.Describe())
+	}
+	if !IsDog(dog) || !IsAnimal(dog) || IsCat(dog) || !dog.IsA("Animal") || dog.IsA("Cat") {
+		t.Error("Wrong class checks of a Dog")
+	}
+	if _, ok := AsCat(dog); ok {
+		t.Error("A Dog was cast to a Cat")
+	}
+	d, ok := AsDog(dog)
+	if !ok || d.Class() != "Dog" {
+		t.Error("A Dog was not cast to a Dog")
+	}
+	if _, ok := AsAnimal(NewCat("Tom")); !ok {
+		t.Error("A Cat was not cast to an Animal")
+	}
+	if _, ok := AsCat(NewAnimal("Generic")); ok {
+		t.Error("An Animal was cast to a Cat")
+	}
+	if a, ok := gopp.As[AnimalI](dog); !ok || a.Sound() != "woof" {
+		t.Error("A Dog was not cast to an AnimalI")
+	}
+	if IsAnimal(nil) {
+		t.Error("nil is an Animal")
+	}
+}
@@ -0,0 +1,127 @@
+package json
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spekary/gopp"
+	"run/json/staff"
+)
+
+func TestJSON(t *testing.T) {
+	e := NewEmployee().(*Employee)
+	e.name, e.age, e.id, e.secret, e.company = "Ann", 30, 7, "x", "Acme"
+	e.City = "Paris"
+	friend := NewPerson().(*Person)
+	friend.name = "Bob"
+	e.friend = friend
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") || !strings.Contains(string(data), `"years":30`) {
+		t.Errorf("Wrong JSON: %s", data)
+	}
+
+	var d Employee
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.name != "Ann" || d.City != "Paris" || d.age != 30 || d.company != "Acme" || d.id != 0 || d.secret != "" || d.I() != &d {
+		t.Errorf("Wrong decoded object: %+v", &d)
+	}
+	if f, ok := d.friend.(*Person); !ok || f.name != "Bob" {
+		t.Errorf("Wrong decoded friend: %+v", d.friend)
+	}
+
+	obj, err := gopp.UnmarshalPolymorphic(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := AsEmployee(obj); !ok || p.(*Employee).name != "Ann" {
+		t.Errorf("Wrong polymorphic object: %+v", obj)
+	}
+
+	if err := json.Unmarshal([]byte(`{"name":"Ann"}`), new(Employee)); err == nil {
+		t.Error("Missing required member not reported")
+	}
+	if err := json.Unmarshal(data, new(Person)); err == nil {
+		t.Error("An Employee decoded into a Person")
+	}
+	data, err = json.Marshal(friend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, new(Employee)); err == nil || !strings.Contains(err.Error(), "company") {
+		t.Errorf("A Person without the required company decoded into an Employee: %v", err)
+	}
+}
+
+func TestNoCopy(t *testing.T) {
+	a := NewAccount().(*Account)
+	a.balance = 5
+	a.Lock()
+	defer a.Unlock()
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Mutex") || !strings.Contains(string(data), `"balance":5`) {
+		t.Errorf("Wrong JSON: %s", data)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	for _, obj := range []gopp.BaseI{NewPerson(), staff.NewPerson()} {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c struct {
+			Class string `json:"@class"`
+		}
+		if err := json.Unmarshal(data, &c); err != nil || c.Class != gopp.ClassName(obj) {
+			t.Errorf("Wrong class in %s, want %s", data, gopp.ClassName(obj))
+		}
+		d, err := gopp.UnmarshalPolymorphic(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gopp.ClassName(d) != gopp.ClassName(obj) {
+			t.Errorf("Decoded a %s as a %s", gopp.ClassName(obj), gopp.ClassName(d))
+		}
+	}
+	if gopp.ClassName(NewPerson()) != "run/json.Person" || gopp.ClassName(staff.NewPerson()) != "run/json/staff.Person" {
+		t.Errorf("Wrong qualified names %s and %s", gopp.ClassName(NewPerson()), gopp.ClassName(staff.NewPerson()))
+	}
+
+	if obj, err := gopp.UnmarshalPolymorphic([]byte(`{"@class":"Employee","name":"Ann","company":"Acme"}`)); err != nil {
+		t.Error(err)
+	} else if e, ok := AsEmployee(obj); !ok || e.(*Employee).company != "Acme" {
+		t.Errorf("Wrong object for a bare class name: %+v", obj)
+	}
+	if _, err := gopp.UnmarshalPolymorphic([]byte(`{"@class":"Person"}`)); err == nil {
+		t.Error("An ambiguous bare class name was accepted")
+	}
+
+	// Classes are compared by their qualified names along the superclass chain
+	for _, test := range []struct {
+		data string
+		obj  gopp.BaseI
+		ok   bool
+	}{
+		{`{"@class":"run/json.Person","name":"Ann","company":"Acme"}`, new(Employee), true},
+		{`{"@class":"run/json.Employee","company":"Acme"}`, new(Employee), true},
+		{`{"@class":"Person","company":"Acme"}`, new(Employee), true},
+		{`{"@class":"run/json/staff.Person","company":"Acme"}`, new(Employee), false},
+		{`{"@class":"run/json/staff.Person"}`, new(Person), false},
+		{`{"@class":"run/json.Person","badge":1}`, new(staff.Person), false},
+		{`{"@class":"run/json/other.Person"}`, new(Person), false},
+	} {
+		if err := json.Unmarshal([]byte(test.data), test.obj); (err == nil) != test.ok {
+			t.Errorf("Decoding %s into a %s: %v", test.data, gopp.ClassName(test.obj), err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package members
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMembers(t *testing.T) {
+	w := NewWidget().(*Widget)
+	w.x, w.y, w.title = 1, 2, "t"
+	w.inner.a, w.inner.b = 3, "b"
+	w.callback = func(n int) int { return n * 2 }
+	w.extra = extra{5, []string{"a"}}
+	w.label = &label{"l"}
+
+	c := w.Clone().(*Widget)
+	if c.x != 1 || c.y != 2 || c.title != "t" || c.inner.a != 3 || c.inner.b != "b" || c.callback(2) != 4 {
+		t.Errorf("Members not cloned: %+v", c)
+	}
+	if c.n != 5 || len(c.tags) != 1 || c.tags[0] != "a" || c.label != w.label {
+		t.Errorf("Embedded members not cloned: %+v", c)
+	}
+
+	f, _ := reflect.TypeOf(Widget{}).FieldByName("title")
+	if f.Tag != `xml:"t" json:"heading" db:"title_col)" gopp:"readonly"` {
+		t.Errorf("Wrong struct tag: %s", f.Tag)
+	}
+}
+
+func TestNoCopy(t *testing.T) {
+	a := NewCounter().(*Counter)
+	a.n = 1
+	a.hits.Store(5)
+	a.cache.names = []string{"a"}
+	a.Lock()
+	defer a.Unlock()
+
+	c := a.Clone().(*Counter)
+	if c.n != 1 || c.hits.Load() != 0 || c.cache.names != nil {
+		t.Errorf("Wrong clone: n %d, hits %d, names %v", c.n, c.hits.Load(), c.cache.names)
+	}
+	if !c.TryLock() {
+		t.Error("The lock of the clone is held")
+	}
+	if !a.Equal(c) || a.Hash() != c.Hash() {
+		t.Error("Members that are not copied are compared")
+	}
+	c.n = 2
+	if a.Equal(c) {
+		t.Error("Counters with different members are equal")
+	}
+}
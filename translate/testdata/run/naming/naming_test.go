@@ -0,0 +1,39 @@
+package naming
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReceivers(t *testing.T) {
+	c := NewCircle().(*circle)
+	c.r = 2
+	if d := c.Clone().(Circle); !d.Equal(c) || !d.IsA("Circle") || d.Hash() != c.Hash() {
+		t.Error("Wrong clone of a Circle")
+	}
+	o, p := NewOrb().(*orb), NewOrb().(*orb)
+	o.depth, p.depth = 1, 2
+	if o.Equal(p) || !o.Equal(o.Clone().(Orb)) {
+		t.Error("Wrong equality of Orbs")
+	}
+
+	f := NewFrame(3).(*frame)
+	f.points = []int{1}
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := new(frame)
+	if err := json.Unmarshal(data, g); err != nil || g.width != 3 || len(g.points) != 1 || !g.IsA("Mesh") {
+		t.Errorf("Wrong decoded Frame %+v: %v", g, err)
+	}
+	if err := json.Unmarshal([]byte(`{"width":1}`), new(frame)); err == nil {
+		t.Error("Missing required member not reported")
+	}
+	if err := json.Unmarshal([]byte(`{"x":1}`), new(dot)); err != nil {
+		t.Error(err)
+	}
+	if p := NewPin(4).(*pin); p.x != 4 || !p.IsA("Mark") {
+		t.Errorf("Wrong Pin %+v", p)
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Package translate converts gopp source code, which is normally found in .gpp files, into Go code. It is the library
+behind the gopp command, and can be used to run gopp from other tools.
+
+To translate a single file, call Translate. To translate the files of a package, so that classes can extend classes
+that are declared in other files, create a Session, Add all of the files to it, and then Generate each one.
+*/
+package translate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Version is the version of gopp. It is part of the hash of the inputs of generated files, so that files are generated
+// again when gopp changes.
+const Version = "0.3.0"
+
+// generatedHeader is the comment at the top of every generated file, in the form that go tools use to recognize
+// generated files. It is followed by a line that holds the hash of the inputs of the file.
+const generatedHeader = "// Code generated by gopp. DO NOT EDIT.\n\n\n"
+
+// oldGeneratedHeader is the comment at the top of files generated by earlier versions of gopp.
+const oldGeneratedHeader = "//** This file is code generated by gopp. Do not edit."
+
+// Options control the optional parts of the generated code.
+type Options struct {
+	JSON      bool              // generate MarshalJSON and UnmarshalJSON functions for each class
+	Naming    Naming            // the names of the generated types and functions
+	Templates map[string]string // the sources of templates that replace the templates of the same names
+}
+
+// Result is the result of translating a .gpp file.
+type Result struct {
+	Source      []byte // the generated Go code, which is nil if there were errors
+	Diagnostics Diagnostics
+	Classes     []ClassInfo       // the classes declared in the file
+	Files       map[string][]byte // the files that generators write beside the generated file, by suffix
+}
+
+// Diagnostic describes a problem found in a .gpp file.
+type Diagnostic struct {
+	File    string
+	Line    int // the line of the problem, starting at 1, or 0 if the line is not known
+	Column  int // the column of the problem, starting at 1, or 0 if the column is not known
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	if d.Line > 0 && d.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+	}
+	if d.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+	}
+	if d.File != "" {
+		return d.File + ": " + d.Message
+	}
+	return d.Message
+}
+
+// Diagnostics is a list of problems. It is returned as the error of functions in this package, so that all of the
+// problems can be reported.
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	var lines []string
+	for _, diag := range d {
+		lines = append(lines, diag.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ClassInfo describes a class declared in a .gpp file, along with the names of the Go code generated for it.
+type ClassInfo struct {
+	Name        string
+	Extends     string // the superclass, which includes its package if it is in a different package
+	Package     string // the name of the package the class is declared in
+	File        string
+	Comment     string
+	Modifiers   []string
+	Members     []MemberInfo
+	Methods     []MethodInfo
+	Interface   string   // the name of the interface of the class
+	Struct      string   // the name of the struct of the class
+	Constructor string   // the name of the function that creates an object of the class
+	Receiver    string   // the name of the receiver of the methods of the class
+	Parents     []string // the superclasses declared in the package, starting with its superclass
+
+	Decl *ClassDecl `json:"-"` // the declaration of the class in the syntax tree of its file
+}
+
+// MemberInfo describes a member variable of a class.
+type MemberInfo struct {
+	Names       []string // the names of the variables, which is empty for an embedded type
+	Type        string
+	Tag         string // the struct tag, including the tags created from annotations
+	Annotations []Annotation
+	Default     string // the expression of the default value, if any
+	Comment     string
+}
+
+// MethodInfo describes a method of a class.
+type MethodInfo struct {
+	Name       string
+	Params     string // the parameters and results of the method
+	IsOverride bool
+	Comment    string
+}
+
+// Translate translates the .gpp source code in src, which comes from the named file, into Go code. Classes in the
+// file can only extend classes in the same file, or in other packages. The returned error is a Diagnostics that holds
+// the same problems as the Diagnostics of the result.
+func Translate(filename string, src []byte, opts Options) (*Result, error) {
+	s := NewSession(opts)
+	if err := s.Add(filename, src); err != nil {
+		return &Result{Diagnostics: err.(Diagnostics)}, err
+	}
+	return s.Generate(filename)
+}
+
+/**
+Process a string that is gopp formatted code, and return the go code. Unlike Translate, the generated header comment
+is not added and the code is not formatted. Each call is independent of the others, so it can be called concurrently.
+*/
+func ProcessString(input string) (string, error) {
+	return processString(input, Options{})
+}
+
+func processString(input string, opts Options) (string, error) {
+	s := NewSession(opts)
+	tree, err := s.parseString("", input)
+	if err != nil {
+		return "", err
+	}
+	out, _, err := s.generate(tree)
+	return out, err
+}
+
+// info returns the description of the class.
+func (c *classDef) info() ClassInfo {
+	info := ClassInfo{
+		Name:        c.Name,
+		Extends:     c.Extends,
+		File:        c.file,
+		Comment:     c.Comment,
+		Interface:   c.Interface,
+		Struct:      c.Struct,
+		Constructor: c.Constructor,
+		Receiver:    c.Receiver,
+		Decl:        c.decl,
+	}
+	if c.scope != nil {
+		info.Package = c.scope.name
+	}
+	if c.Equatable {
+		info.Modifiers = append(info.Modifiers, modEquatable)
+	}
+	for _, m := range c.Members {
+		info.Members = append(info.Members, MemberInfo{
+			Names:       m.Names,
+			Type:        m.Type,
+			Tag:         m.Tag,
+			Annotations: m.Annotations,
+			Default:     m.Default,
+			Comment:     m.Comment,
+		})
+	}
+	for _, f := range c.Funcs {
+		info.Methods = append(info.Methods, MethodInfo{
+			Name:       f.Name,
+			Params:     f.Params,
+			IsOverride: f.IsOverride,
+			Comment:    f.Comment,
+		})
+	}
+	for _, p := range c.Parents() {
+		info.Parents = append(info.Parents, p.Name)
+	}
+	return info
+}
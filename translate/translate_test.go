@@ -0,0 +1,228 @@
+package translate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	src := `package test
+
+class Point extends gopp.Base equatable {
+	x, y int @json("-") = 1, 2
+
+	func Len() int {
+		return this.x + this.y
+	}
+}
+`
+	r, err := Translate("point.gpp", []byte(src), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsGenerated(r.Source) || !strings.Contains(string(r.Source), "type PointI interface {") {
+		t.Error("Source not generated: " + string(r.Source))
+	}
+	if len(r.Classes) != 1 {
+		t.Fatalf("Expected 1 class, got %d", len(r.Classes))
+	}
+	c := r.Classes[0]
+	if c.Name != "Point" || c.Extends != "gopp.Base" || c.Package != "test" || c.File != "point.gpp" ||
+		len(c.Modifiers) != 1 || c.Modifiers[0] != "equatable" {
+		t.Errorf("Wrong class info: %+v", c)
+	}
+	if len(c.Members) != 1 || len(c.Members[0].Names) != 2 || c.Members[0].Default != "1, 2" ||
+		len(c.Members[0].Annotations) != 1 || c.Members[0].Annotations[0].Value != "-" {
+		t.Errorf("Wrong member info: %+v", c.Members)
+	}
+	if len(c.Methods) != 1 || c.Methods[0].Name != "Len" || c.Methods[0].Params != "() int" {
+		t.Errorf("Wrong method info: %+v", c.Methods)
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	r, err := Translate("bad.gpp", []byte("package test\n\nclass A extends B {\n\tfunc\n"), Options{})
+	diags, ok := err.(Diagnostics)
+	if !ok || len(diags) == 0 || diags[0].File != "bad.gpp" || r.Source != nil {
+		t.Errorf("Error not reported: %v", err)
+	}
+}
+
+func TestTranslateSyntaxError(t *testing.T) {
+	src := `package test
+
+class Point extends gopp.Base {
+	x int
+
+	func Len() int {
+		a := this.x
+		return a +
+	}
+}
+`
+	r, err := Translate("point.gpp", []byte(src), Options{})
+	diags, ok := err.(Diagnostics)
+	if !ok || len(diags) == 0 || diags[0].Line != 9 || r.Source != nil {
+		t.Errorf("Syntax error not mapped to its line: %v", err)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	s := NewSession(Options{})
+	files := map[string]string{
+		"a.gpp": "package test\n\nclass A extends gopp.Base {\n}\n",
+		"b.gpp": "package test\n\nclass B extends A {\n}\n",
+		"c.gpp": "package test\n\nclass C extends B {\n}\n",
+		"d.gpp": "package test\n\nclass D extends gopp.Base {\n}\n",
+	}
+	for _, name := range []string{"a.gpp", "b.gpp", "c.gpp", "d.gpp"} {
+		if err := s.Add(name, []byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if deps := s.Dependents("a.gpp"); len(deps) != 2 || deps[0] != "b.gpp" || deps[1] != "c.gpp" {
+		t.Errorf("Wrong dependents of a.gpp: %v", deps)
+	}
+
+	// Replacing b.gpp removes class B, so C no longer depends on a.gpp
+	if err := s.Add("b.gpp", []byte("package test\n\nclass B2 extends A {\n}\n")); err != nil {
+		t.Fatal(err)
+	}
+	if deps := s.Dependents("a.gpp"); len(deps) != 1 || deps[0] != "b.gpp" {
+		t.Errorf("Wrong dependents after replacing b.gpp: %v", deps)
+	}
+
+	// A version with errors does not replace the file
+	if err := s.Add("a.gpp", []byte("package test\n\nclass A extends {\n")); err == nil {
+		t.Error("Error not reported")
+	}
+	s.Remove("d.gpp")
+	if err := s.Add("e.gpp", []byte("package test\n\nclass E extends A {\n}\nclass D extends gopp.Base {\n}\n")); err != nil {
+		t.Error(err)
+	}
+	if deps := s.Dependents("a.gpp"); len(deps) != 2 || deps[1] != "e.gpp" {
+		t.Errorf("Wrong dependents after removing d.gpp: %v", deps)
+	}
+}
+
+func TestHash(t *testing.T) {
+	s := NewSession(Options{})
+	s.Add("a.gpp", []byte("package test\n\nclass A extends gopp.Base {\n}\n"))
+	s.Add("b.gpp", []byte("package test\n\nclass B extends A {\n}\n"))
+	s.Add("c.gpp", []byte("package test\n\nclass C extends gopp.Base {\n}\n"))
+
+	r, err := s.Generate("b.gpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := s.Hash("b.gpp")
+	if GeneratedHash(r.Source) != hash {
+		t.Errorf("Hash not recorded in the header: %s", r.Source)
+	}
+
+	s.Add("c.gpp", []byte("package test\n\nclass C extends gopp.Base {\n\tx int\n}\n"))
+	if s.Hash("b.gpp") != hash {
+		t.Error("Hash changed when an unrelated file changed")
+	}
+	s.Add("a.gpp", []byte("package test\n\nclass A extends gopp.Base {\n\tx int\n}\n"))
+	if s.Hash("b.gpp") == hash {
+		t.Error("Hash did not change when the file of the superclass changed")
+	}
+	if NewSession(Options{JSON: true}).Hash("b.gpp") == NewSession(Options{}).Hash("b.gpp") {
+		t.Error("Hash did not change with the options")
+	}
+}
+
+func TestGenerateAll(t *testing.T) {
+	var files []Source
+	var names []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("f%02d.gpp", i)
+		parent := "gopp.Base"
+		if i > 0 {
+			parent = fmt.Sprintf("C%d", i-1)
+		}
+		src := fmt.Sprintf("package test\n\nclass C%d extends %s equatable {\n\tx%d int = %d\n\n\tfunc Get%d() int {\n\t\treturn this.x%d\n\t}\n}\n", i, parent, i, i, i, i)
+		files = append(files, Source{name, []byte(src)})
+		names = append(names, name)
+	}
+	files = append(files, Source{"bad.gpp", []byte("package test\n\nclass Bad extends {\n")})
+
+	generate := func(jobs int) []*Result {
+		s := NewSession(Options{JSON: true})
+		errs := s.AddAll(files, jobs)
+		for i, err := range errs[:len(names)] {
+			if err != nil {
+				t.Fatalf("%s: %v", names[i], err)
+			}
+		}
+		if errs[len(names)] == nil {
+			t.Error("Error not reported for bad.gpp")
+		}
+		results, errs := s.GenerateAll(names, jobs)
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("%s: %v", names[i], err)
+			}
+		}
+		return results
+	}
+
+	serial := generate(1)
+	parallel := generate(8)
+	for i := range serial {
+		if !bytes.Equal(serial[i].Source, parallel[i].Source) {
+			t.Errorf("%s is different when generated in parallel", names[i])
+		}
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	r, err := Translate("a.gpp", []byte("package test\n\nclass A extends gopp.Base {\n}\n"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The form that go tools recognize
+	if !regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`).Match(r.Source) || !IsGenerated(r.Source) {
+		t.Errorf("Generated code not recognized: %s", r.Source)
+	}
+	if !IsGenerated([]byte("//** This file is code generated by gopp. Do not edit.\n\npackage test\n")) {
+		t.Error("Header of earlier versions not recognized")
+	}
+	if IsGenerated([]byte("package test\n")) {
+		t.Error("Code not generated by gopp recognized")
+	}
+}
+
+func TestNaming(t *testing.T) {
+	s := NewSession(Options{})
+	s.SetConfig("shapes", Config{Naming: Naming{Interface: "{Name}", Struct: "{name}", Receiver: "{n}"}})
+	s.Add("shapes/shape.gpp", []byte("package shapes\n\nclass Shape extends gopp.Base equatable {\n\tfunc Area() int {\n\t\treturn 0\n\t}\n}\n"))
+	s.Add("shapes/square.gpp", []byte("package shapes\n\nclass Square extends Shape {\n\tfunc Describe() int {\n\t\treturn this.Area()\n\t}\n}\n"))
+	s.Add("other/point.gpp", []byte("package other\n\nclass Point extends gopp.Base {\n}\n"))
+
+	r, err := s.Generate("shapes/square.gpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"type Square interface {\n\tShape\n",
+		"type square struct {\n\tshape\n",
+		"func NewSquare() Square {",
+		"func (s *square) Describe() int {\n\treturn s.I().(Square).Area()",
+		"return s.shape.IsA(gopp_className)",
+	} {
+		if !strings.Contains(string(r.Source), want) {
+			t.Errorf("Missing %q in:\n%s", want, r.Source)
+		}
+	}
+	if r, err := s.Generate("shapes/shape.gpp"); err != nil || !strings.Contains(string(r.Source), "Equal(other Shape) bool") {
+		t.Errorf("Wrong equal type: %v", err)
+	}
+	if r, err := s.Generate("other/point.gpp"); err != nil || !strings.Contains(string(r.Source), "type PointI interface {\n\tgopp.BaseI") {
+		t.Errorf("Naming applied to another directory: %v", err)
+	}
+}